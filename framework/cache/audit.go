@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LanguageAudit reports how a route's cached languages compare to the configured ones.
+type LanguageAudit struct {
+	Canonical string
+	Cached    []string // Configured languages with a cached entry
+	Missing   []string // Configured languages without a cached entry
+	Orphaned  []string // Cached languages that are no longer configured
+}
+
+// AuditLanguages compares the cached entries of every cacheable route against
+// config.Languages, e.g. after adding or removing a language, to guide
+// WarmLanguage and targeted purges. Dynamic and parameterized routes are skipped.
+// Orphans on disk are found through their metadata sidecars.
+func (m *Manager) AuditLanguages(config RebuildConfig) ([]LanguageAudit, error) {
+	routes, err := loadRoutes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := make(map[string]bool, len(config.Languages))
+	for _, lang := range config.Languages {
+		configured[normalizeLanguage(lang, GetKeyOptions().StripRegion)] = true
+	}
+
+	// Languages of cached keys outside the configured set
+	orphanLangs := make(map[string]bool)
+	cachedKeys := make(map[string]bool)
+	m.entryMap().Range(func(key, _ interface{}) bool {
+		cachedKeys[key.(string)] = true
+		return true
+	})
+	if !m.diskless {
+		metas, err := m.storage.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache metadata: %w", err)
+		}
+		for key := range metas {
+			cachedKeys[key] = true
+		}
+	}
+	for key := range cachedKeys {
+		if lang := keyLanguage(key); lang != "" && !configured[lang] {
+			orphanLangs[lang] = true
+		}
+	}
+
+	var audits []LanguageAudit
+	for _, route := range routes {
+		if route.Strategy == "dynamic" || strings.Contains(route.Canonical, "{") {
+			continue
+		}
+
+		audit := LanguageAudit{Canonical: route.Canonical}
+		for _, lang := range config.Languages {
+			if m.isCached(GetCacheKey(route.Canonical, lang, nil)) {
+				audit.Cached = append(audit.Cached, lang)
+			} else {
+				audit.Missing = append(audit.Missing, lang)
+			}
+		}
+		for lang := range orphanLangs {
+			if cachedKeys[GetCacheKey(route.Canonical, lang, nil)] {
+				audit.Orphaned = append(audit.Orphaned, lang)
+			}
+		}
+		sort.Strings(audit.Orphaned)
+
+		audits = append(audits, audit)
+	}
+
+	sort.Slice(audits, func(i, j int) bool {
+		return audits[i].Canonical < audits[j].Canonical
+	})
+
+	return audits, nil
+}
+
+// isCached reports whether a key has an entry in memory or on disk,
+// without loading it into memory.
+func (m *Manager) isCached(cacheKey string) bool {
+	if _, ok := m.entryMap().Load(cacheKey); ok {
+		return true
+	}
+	return !m.diskless && m.storage.Exists(cacheKey)
+}
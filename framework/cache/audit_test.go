@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAuditLanguages(t *testing.T) {
+	m := newTestManager(t)
+	seed(t, m, "static", "/about:en", "/about:de", "/contact:fr")
+
+	// A removed language may only be left on disk
+	m.entryMap().Delete("/contact:fr")
+
+	var renders sync.Map
+	config := testRebuildConfig(t, pathRouter(&renders), []string{"en", "tr"},
+		route("/contact", "static"), route("/about", "static"), route("/search", "dynamic"))
+
+	audits, err := m.AuditLanguages(config)
+	if err != nil {
+		t.Fatalf("AuditLanguages: %v", err)
+	}
+
+	want := []LanguageAudit{
+		{Canonical: "/about", Cached: []string{"en"}, Missing: []string{"tr"}, Orphaned: []string{"de"}},
+		{Canonical: "/contact", Missing: []string{"en", "tr"}, Orphaned: []string{"fr"}},
+	}
+	if !reflect.DeepEqual(audits, want) {
+		t.Errorf("AuditLanguages = %+v, want %+v", audits, want)
+	}
+}
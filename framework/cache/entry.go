@@ -5,19 +5,47 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+const (
+	// incrementalTTL is how long an incremental entry stays fresh after rendering.
+	incrementalTTL = 24 * time.Hour
+
+	// defaultTTLJitter spreads incremental expirations by up to ±10% of the TTL,
+	// so pages warmed together don't all go stale at the same instant.
+	defaultTTLJitter = 0.1
+)
+
 // Entry represents a cached page with metadata.
+// All exported fields except Strategy are guarded by mu;
+// use Snapshot to read them while the entry may be updated concurrently.
 type Entry struct {
-	Content     []byte    // Brotli-compressed HTML stored in memory
-	RenderedAt  time.Time // When this entry was last rendered
-	Strategy    string    // Caching strategy: "static", "incremental", "dynamic", "immutable"
-	ETag        string    // HTTP ETag for cache validation
-	RequestPath string    // Original request path for eager revalidation
-	Generation  int64     // Generation number - increments on each update
-	stale       atomic.Bool
+	Content          []byte        // Brotli-compressed HTML stored in memory (original HTML when Uncompressed)
+	RenderedAt       time.Time     // When this entry was last rendered
+	Strategy         string        // Caching strategy: "static", "incremental", "dynamic", "immutable"
+	ETag             string        // HTTP ETag for cache validation
+	RequestPath      string        // Original request path for eager revalidation
+	Generation       int64         // Generation number - increments on each update
+	Status           int           // Response status: 301/308 for redirects, or a non-200 cacheable status; 0 means 200
+	Location         string        // Redirect target when Status is set
+	CompressionRatio float64       // Compressed size divided by uncompressed size
+	ContentType      string        // Stored Content-Type; empty means HTML
+	Uncompressed     bool          // Content is stored as-is because brotli didn't shrink it
+	DataVersion      string        // Version of the underlying data when rendered, for data-driven strategies
+	ttlJitter        time.Duration // Per-key offset added to the incremental TTL
+	stale            atomic.Bool
+	pinned           atomic.Bool
+	hits             atomic.Int64                   // Times served from the cache, for eviction
+	lastAccess       atomic.Int64                   // Unix nanoseconds of the last access, for eviction
+	persistedGen     atomic.Int64                   // Generation whose content is on disk (0 = none), for eviction
+	revalidate       atomic.Pointer[RevalidateFunc] // Predicate registered for the strategy, if any
+	mu               sync.RWMutex                   // Guards content fields against concurrent updates
+	writeMu          sync.Mutex                     // Serializes disk writes so older content never overwrites newer
 }
 
 // NewEntry creates a new cache entry with the given content and strategy.
@@ -45,27 +73,147 @@ func (e *Entry) MarkStale() {
 	e.stale.Store(true)
 }
 
+// IsPinned returns whether this entry is pinned against staleness and eviction.
+func (e *Entry) IsPinned() bool {
+	return e.pinned.Load()
+}
+
 // MarkFresh marks this entry as fresh (valid cache).
 func (e *Entry) MarkFresh() {
 	e.stale.Store(false)
 }
 
+// Touch marks the entry fresh and resets its render time without changing
+// its content or ETag, e.g. after a conditional re-render found it unchanged.
+func (e *Entry) Touch() {
+	e.mu.Lock()
+	e.RenderedAt = time.Now()
+	e.mu.Unlock()
+	e.stale.Store(false)
+}
+
 // Update updates the entry content and marks it as fresh.
-func (e *Entry) Update(content []byte, requestPath string) {
-	e.Content = content
+// Concurrent updates are serialized; the returned generation belongs to this update.
+func (e *Entry) Update(content []byte, requestPath string) int64 {
+	return e.update(revision{content: content, requestPath: requestPath})
+}
+
+// revision carries the values replaced by an entry update.
+type revision struct {
+	content          []byte // Compressed content
+	requestPath      string
+	status           int
+	location         string
+	compressionRatio float64
+	contentType      string
+	uncompressed     bool   // Content is the original, not brotli output
+	dataVersion      string // Version of the underlying data the content was rendered from
+	conditional      bool   // Only apply when the entry is at expectGeneration
+	expectGeneration int64  // Required current generation; 0 means the entry must not exist
+}
+
+// update replaces the entry content and its response metadata.
+// Conditional revisions return 0 without applying when the generation has moved on.
+func (e *Entry) update(rev revision) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if rev.conditional && e.Generation != rev.expectGeneration {
+		return 0
+	}
+
+	e.Content = rev.content
+	e.Status = rev.status
+	e.Location = rev.location
+	e.CompressionRatio = rev.compressionRatio
+	e.ContentType = rev.contentType
+	e.Uncompressed = rev.uncompressed
+	e.DataVersion = rev.dataVersion
 	e.RenderedAt = time.Now()
 	e.Generation++
-	e.ETag = generateETag(content, e.Generation, e.RenderedAt)
-	if requestPath != "" {
-		e.RequestPath = requestPath
+	e.ETag = generateETag(rev.content, e.Generation, e.RenderedAt)
+	if rev.requestPath != "" {
+		e.RequestPath = rev.requestPath
 	}
 	e.MarkFresh()
+
+	return e.Generation
+}
+
+// Snapshot returns the content, ETag and generation as a consistent set.
+func (e *Entry) Snapshot() (content []byte, etag string, generation int64) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Content, e.ETag, e.Generation
+}
+
+// DecompressedSnapshot returns the original content with its ETag and generation
+// as a consistent set, decompressing it unless it was stored uncompressed.
+func (e *Entry) DecompressedSnapshot() (content []byte, etag string, generation int64, err error) {
+	e.mu.RLock()
+	content, etag, generation = e.Content, e.ETag, e.Generation
+	uncompressed := e.Uncompressed
+	e.mu.RUnlock()
+
+	if uncompressed {
+		return content, etag, generation, nil
+	}
+	content, err = DecompressBrotli(content)
+	return content, etag, generation, err
+}
+
+// Redirect returns the cached redirect status and location.
+// A zero status means the entry is a regular page.
+func (e *Entry) Redirect() (status int, location string) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.Location == "" {
+		return 0, ""
+	}
+	return e.Status, e.Location
+}
+
+// ResponseStatus returns the HTTP status a cached page is replayed with.
+func (e *Entry) ResponseStatus() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.Status == 0 {
+		return http.StatusOK
+	}
+	return e.Status
+}
+
+// StoredContentType returns the Content-Type to serve the entry with.
+func (e *Entry) StoredContentType() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.ContentType == "" {
+		return "text/html; charset=utf-8"
+	}
+	return e.ContentType
+}
+
+// Age returns how long ago the entry was rendered.
+func (e *Entry) Age() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return time.Since(e.RenderedAt)
+}
+
+// CurrentGeneration returns the entry's current generation number.
+func (e *Entry) CurrentGeneration() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.Generation
 }
 
 // ShouldRevalidate determines if this entry should be revalidated based on strategy.
 func (e *Entry) ShouldRevalidate() bool {
-	// Immutable entries never revalidate
-	if e.Strategy == "immutable" {
+	// Immutable and pinned entries never revalidate
+	if e.Strategy == "immutable" || e.IsPinned() {
 		return false
 	}
 
@@ -74,15 +222,41 @@ func (e *Entry) ShouldRevalidate() bool {
 		return true
 	}
 
+	// Registered predicates can force revalidation, e.g. when the data changed
+	if fn := e.revalidate.Load(); fn != nil && (*fn)(e) {
+		return true
+	}
+
 	// Incremental entries revalidate if older than 24 hours
 	if e.Strategy == "incremental" {
-		return time.Since(e.RenderedAt) > 24*time.Hour
+		return time.Now().After(e.expiresAt())
 	}
 
 	// Static entries only revalidate when explicitly marked stale
 	return false
 }
 
+// expiresAt returns when an incremental entry's jittered TTL runs out.
+func (e *Entry) expiresAt() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.RenderedAt.Add(incrementalTTL + e.ttlJitter)
+}
+
+// ttlJitter maps a hash of the key into ±fraction of the incremental TTL.
+// The offset is deterministic, so a key expires at the same time across restarts.
+func ttlJitter(cacheKey string, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(cacheKey))
+	position := float64(h.Sum64()%10001)/10000*2 - 1 // In [-1, 1]
+
+	return time.Duration(position * fraction * float64(incrementalTTL))
+}
+
 // generateETag generates an ETag from content, generation, and timestamp using SHA-256.
 func generateETag(content []byte, generation int64, renderedAt time.Time) string {
 	h := sha256.New()
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Sentinel errors describing the class of a cache failure.
+// Match them with errors.Is; use errors.As with *Error for the key and operation.
+var (
+	ErrNotFound = errors.New("cache entry not found")
+	ErrCorrupt  = errors.New("cache entry corrupt")
+	ErrStorage  = errors.New("cache storage failure")
+	ErrRejected = errors.New("cache content rejected by validator")
+	ErrConflict = errors.New("cache entry generation changed")
+	ErrGone     = errors.New("cache entry tombstoned")
+)
+
+// Error describes a failed cache operation.
+type Error struct {
+	Op   string // Operation that failed, e.g. "read brotli file"
+	Key  string // Cache key involved, if any
+	Kind error  // One of ErrNotFound, ErrCorrupt, ErrStorage, ErrRejected, ErrConflict or ErrGone
+	Err  error  // Underlying error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("failed to %s for %s: %v", e.Op, e.Key, e.Err)
+	}
+	return fmt.Sprintf("failed to %s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is this error's kind.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// newError creates a cache error of the given kind.
+func newError(op, key string, kind, err error) *Error {
+	return &Error{
+		Op:   op,
+		Key:  key,
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+// newFileError classifies a file system error as ErrNotFound or ErrStorage.
+func newFileError(op, key string, err error) *Error {
+	if os.IsNotExist(err) {
+		return newError(op, key, ErrNotFound, err)
+	}
+	return newError(op, key, ErrStorage, err)
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		kind error
+		run  func(t *testing.T, m *Manager) error
+	}{
+		{
+			name: "missing file",
+			kind: ErrNotFound,
+			run: func(t *testing.T, m *Manager) error {
+				_, err := m.storage.ReadHTML("/missing:en")
+				return err
+			},
+		},
+		{
+			name: "corrupt metadata",
+			kind: ErrCorrupt,
+			run: func(t *testing.T, m *Manager) error {
+				path := filepath.Join(m.storage.baseDir, getCacheFileName("/broken:en")+metaExt)
+				if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+					t.Fatalf("write sidecar: %v", err)
+				}
+				_, err := m.storage.ReadMeta("/broken:en")
+				return err
+			},
+		},
+		{
+			name: "corrupt content",
+			kind: ErrCorrupt,
+			run: func(t *testing.T, m *Manager) error {
+				_, err := DecompressBrotli([]byte("not brotli"))
+				return err
+			},
+		},
+		{
+			name: "failed write",
+			kind: ErrStorage,
+			run: func(t *testing.T, m *Manager) error {
+				m.storage.writer = func(string, []byte, os.FileMode) error { return syscall.EACCES }
+				return m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about")
+			},
+		},
+		{
+			name: "rejected content",
+			kind: ErrRejected,
+			run: func(t *testing.T, m *Manager) error {
+				m.SetContentValidator(RejectMarkers("Internal Server Error"))
+				return m.SetSync("/about:en", []byte("<h1>Internal Server Error</h1>"), "static", "/about")
+			},
+		},
+		{
+			name: "generation conflict",
+			kind: ErrConflict,
+			run: func(t *testing.T, m *Manager) error {
+				if err := m.SetSync("/about:en", []byte("<p>v1</p>"), "static", "/about"); err != nil {
+					t.Fatalf("SetSync: %v", err)
+				}
+				rev := revision{requestPath: "/about", conditional: true, expectGeneration: 7}
+				return m.setPage("/about:en", []byte("<p>v2</p>"), "static", rev, true)
+			},
+		},
+	}
+
+	kinds := []error{ErrNotFound, ErrCorrupt, ErrStorage, ErrRejected, ErrConflict}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run(t, newTestManager(t))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, kind := range kinds {
+				if got, want := errors.Is(err, kind), kind == tt.kind; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, kind, got, want)
+				}
+			}
+
+			var cacheErr *Error
+			if !errors.As(err, &cacheErr) || cacheErr.Op == "" {
+				t.Errorf("error %v does not carry the failed operation", err)
+			}
+		})
+	}
+}
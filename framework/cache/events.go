@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of cache event.
+type EventType string
+
+const (
+	EventSet       EventType = "set"
+	EventDelete    EventType = "delete"
+	EventMarkStale EventType = "mark_stale"
+	EventEvict     EventType = "evict"
+)
+
+// eventBufferSize is the default per-subscriber channel capacity.
+const eventBufferSize = 64
+
+// DeliveryPolicy decides what happens when a subscriber's buffer is full.
+type DeliveryPolicy string
+
+const (
+	DeliveryDrop  DeliveryPolicy = "drop"  // Discard the event and count it in Stats.DroppedEvents
+	DeliveryBlock DeliveryPolicy = "block" // Wait for the subscriber, applying backpressure to writers
+)
+
+// SubscribeConfig configures an event subscription.
+type SubscribeConfig struct {
+	Buffer int            // Channel capacity (default: 64)
+	Policy DeliveryPolicy // Behavior when the buffer is full (default: DeliveryDrop)
+}
+
+// DefaultSubscribeConfig returns default configuration.
+func DefaultSubscribeConfig() SubscribeConfig {
+	return SubscribeConfig{
+		Buffer: eventBufferSize,
+		Policy: DeliveryDrop,
+	}
+}
+
+// subscriber is a channel returned by Subscribe along with its delivery policy.
+type subscriber struct {
+	ch     chan CacheEvent
+	policy DeliveryPolicy
+	done   chan struct{} // Closed by Unsubscribe to release a blocked delivery
+	mu     sync.Mutex    // Serializes delivery with closing ch
+	closed bool
+}
+
+// CacheEvent describes a change to a cache entry.
+type CacheEvent struct {
+	Type     EventType
+	Key      string
+	Strategy string
+	Time     time.Time
+}
+
+// Subscribe returns a channel receiving cache events.
+// Delivery is best effort: events are dropped when the subscriber's buffer is full,
+// so a slow reader never stalls request handling. Call Unsubscribe when done.
+func (m *Manager) Subscribe() <-chan CacheEvent {
+	return m.SubscribeWithConfig(DefaultSubscribeConfig())
+}
+
+// SubscribeWithConfig returns a channel receiving cache events with the given
+// buffer size and delivery policy. With DeliveryBlock no event is lost, but cache
+// writes wait for the subscriber to keep up. Call Unsubscribe when done.
+func (m *Manager) SubscribeWithConfig(config SubscribeConfig) <-chan CacheEvent {
+	if config.Buffer < 0 {
+		config.Buffer = 0
+	}
+	if config.Policy == "" {
+		config.Policy = DeliveryDrop
+	}
+
+	sub := &subscriber{
+		ch:     make(chan CacheEvent, config.Buffer),
+		policy: config.Policy,
+		done:   make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, sub)
+
+	return sub.ch
+}
+
+// Unsubscribe stops delivery to a channel returned by Subscribe and closes it.
+func (m *Manager) Unsubscribe(events <-chan CacheEvent) {
+	m.mu.Lock()
+	var sub *subscriber
+	for i, s := range m.subscribers {
+		if s.ch == events {
+			sub = s
+			m.subscribers = append(m.subscribers[:i], m.subscribers[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if sub == nil {
+		return
+	}
+
+	close(sub.done)
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.closed = true
+	close(sub.ch)
+}
+
+// emit delivers an event to all subscribers according to their delivery policy.
+func (m *Manager) emit(eventType EventType, cacheKey, strategy string) {
+	m.mu.RLock()
+	subscribers := append([]*subscriber(nil), m.subscribers...)
+	m.mu.RUnlock()
+
+	if len(subscribers) == 0 {
+		return
+	}
+
+	event := CacheEvent{
+		Type:     eventType,
+		Key:      cacheKey,
+		Strategy: strategy,
+		Time:     time.Now(),
+	}
+
+	for _, sub := range subscribers {
+		if !sub.deliver(event) {
+			m.droppedEvents.Add(1)
+			m.logger.Debug("dropped cache event for slow subscriber",
+				slog.String("type", string(eventType)),
+				slog.String("key", cacheKey),
+			)
+		}
+	}
+}
+
+// deliver sends an event, reporting false when it was dropped because the buffer was full.
+func (s *subscriber) deliver(event CacheEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return true
+	}
+
+	if s.policy == DeliveryBlock {
+		select {
+		case s.ch <- event:
+		case <-s.done:
+		}
+		return true
+	}
+
+	select {
+	case s.ch <- event:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// drain returns the events buffered in the channel without blocking.
+func drain(events <-chan CacheEvent) []CacheEvent {
+	var received []CacheEvent
+	for {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		default:
+			return received
+		}
+	}
+}
+
+func TestSubscribeReceivesEvents(t *testing.T) {
+	m := newTestManager(t)
+	events := m.Subscribe()
+
+	if err := m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	m.MarkStale("static", false)
+	if err := m.Delete("/about:en"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	want := []EventType{EventSet, EventMarkStale, EventDelete}
+	received := drain(events)
+	if len(received) != len(want) {
+		t.Fatalf("received %d events %+v, want %v", len(received), received, want)
+	}
+	for i, event := range received {
+		if event.Type != want[i] || event.Key != "/about:en" || event.Strategy != "static" {
+			t.Errorf("event %d = %+v, want %s for /about:en", i, event, want[i])
+		}
+		if event.Time.IsZero() {
+			t.Errorf("event %d has no time", i)
+		}
+	}
+
+	// Unsubscribing closes the channel and stops delivery
+	m.Unsubscribe(events)
+	if _, open := <-events; open {
+		t.Error("channel still open after Unsubscribe")
+	}
+	if err := m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync after Unsubscribe: %v", err)
+	}
+}
+
+func TestSubscribeDeliveryPolicies(t *testing.T) {
+	keys := []string{"a:en", "b:en", "c:en", "d:en"}
+
+	t.Run("drop", func(t *testing.T) {
+		m := newTestManager(t)
+		events := m.SubscribeWithConfig(SubscribeConfig{Buffer: 1, Policy: DeliveryDrop})
+		defer m.Unsubscribe(events)
+
+		seed(t, m, "static", keys...)
+
+		if got := len(drain(events)); got != 1 {
+			t.Errorf("received %d events, want 1", got)
+		}
+		if got := m.Stats().DroppedEvents; got != int64(len(keys)-1) {
+			t.Errorf("DroppedEvents = %d, want %d", got, len(keys)-1)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		m := newTestManager(t)
+		events := m.SubscribeWithConfig(SubscribeConfig{Buffer: 1, Policy: DeliveryBlock})
+		defer m.Unsubscribe(events)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, key := range keys {
+				m.SetSync(key, []byte("<p>page</p>"), "static", "/"+key)
+			}
+		}()
+
+		// A slow reader still receives every event, in order
+		for _, key := range keys {
+			select {
+			case event := <-events:
+				if event.Key != key {
+					t.Errorf("event for %q, want %q", event.Key, key)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("event for %q never delivered", key)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		<-done
+
+		if got := m.Stats().DroppedEvents; got != 0 {
+			t.Errorf("DroppedEvents = %d, want 0", got)
+		}
+	})
+
+	t.Run("unsubscribe releases blocked writers", func(t *testing.T) {
+		m := newTestManager(t)
+		events := m.SubscribeWithConfig(SubscribeConfig{Buffer: 0, Policy: DeliveryBlock})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			m.SetSync("a:en", []byte("<p>page</p>"), "static", "/a")
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		m.Unsubscribe(events)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("write still blocked after Unsubscribe")
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package cache
+
+import (
+	"container/list"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// EvictionPolicy decides which entries are dropped from memory when the
+// memory budget is exceeded. Evicted entries stay on disk and are reloaded
+// on their next request, so only entries whose current content was written
+// to disk are evicted; pinned entries are never evicted.
+type EvictionPolicy string
+
+const (
+	// EvictLRU drops the least recently accessed entries first.
+	EvictLRU EvictionPolicy = "lru"
+	// EvictStaleFirst drops stale entries first, since they are re-rendered
+	// anyway, then the least frequently accessed fresh entries, so popular
+	// pages that were briefly idle are kept.
+	EvictStaleFirst EvictionPolicy = "stale-first"
+)
+
+// evictionSlack is the fraction of the budget freed beyond the limit on each
+// eviction pass, so a cache at its budget doesn't run a pass on every store.
+const evictionSlack = 0.1
+
+// lruItem is an entry tracked for eviction, in access order.
+type lruItem struct {
+	key   string
+	entry *Entry
+	size  int64
+}
+
+// SetMemoryBudget limits the total size of in-memory entry content in bytes
+// (0 = unlimited) and sets how entries are chosen for eviction.
+func (m *Manager) SetMemoryBudget(bytes int64, policy EvictionPolicy) {
+	if policy == "" {
+		policy = EvictLRU
+	}
+
+	m.mu.Lock()
+	m.eviction = policy
+	m.mu.Unlock()
+
+	// Start tracking from the current entries
+	m.evictMu.Lock()
+	m.budget.Store(bytes)
+	m.resetLRU()
+	m.evictMu.Unlock()
+
+	m.enforceBudget("")
+}
+
+// recordAccess notes that the entry was served, for eviction decisions.
+func (e *Entry) recordAccess() {
+	e.hits.Add(1)
+	e.lastAccess.Store(time.Now().UnixNano())
+}
+
+// resetLRU rebuilds the access list from the current entries, or drops it when
+// no budget is set. Callers must hold m.evictMu.
+func (m *Manager) resetLRU() {
+	m.lru = nil
+	m.lruIndex = nil
+	m.lruBytes = 0
+	if m.budget.Load() <= 0 {
+		return
+	}
+
+	var items []*lruItem
+	m.entryMap().Range(func(key, value interface{}) bool {
+		entry := value.(*Entry)
+		items = append(items, &lruItem{key: key.(string), entry: entry, size: entry.memorySize()})
+		return true
+	})
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].entry.lastAccess.Load() < items[j].entry.lastAccess.Load()
+	})
+
+	m.lru = list.New()
+	m.lruIndex = make(map[string]*list.Element, len(items))
+	for _, item := range items {
+		m.lruIndex[item.key] = m.lru.PushFront(item)
+		m.lruBytes += item.size
+	}
+}
+
+// trackEntry records that the entry stored under key was added, updated or
+// accessed, moving it to the front of the access list.
+func (m *Manager) trackEntry(cacheKey string, entry *Entry) {
+	if m.budget.Load() <= 0 {
+		return
+	}
+
+	m.evictMu.Lock()
+	defer m.evictMu.Unlock()
+
+	if m.lru == nil {
+		return
+	}
+
+	// The entry may have been evicted or replaced since it was stored
+	if current, ok := m.entryMap().Load(cacheKey); !ok || current.(*Entry) != entry {
+		return
+	}
+
+	size := entry.memorySize()
+	if element, ok := m.lruIndex[cacheKey]; ok {
+		item := element.Value.(*lruItem)
+		m.lruBytes += size - item.size
+		item.entry = entry
+		item.size = size
+		m.lru.MoveToFront(element)
+		return
+	}
+
+	m.lruIndex[cacheKey] = m.lru.PushFront(&lruItem{key: cacheKey, entry: entry, size: size})
+	m.lruBytes += size
+}
+
+// untrackEntry drops a key removed from memory from the access list.
+func (m *Manager) untrackEntry(cacheKey string) {
+	if m.budget.Load() <= 0 {
+		return
+	}
+
+	m.evictMu.Lock()
+	defer m.evictMu.Unlock()
+
+	if element, ok := m.lruIndex[cacheKey]; ok {
+		m.lruBytes -= element.Value.(*lruItem).size
+		m.lru.Remove(element)
+		delete(m.lruIndex, cacheKey)
+	}
+}
+
+// memorySize returns the size of the entry's in-memory content.
+func (e *Entry) memorySize() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return int64(len(e.Content))
+}
+
+// evictable reports whether the entry can be dropped from memory without
+// losing data: its current content must be on disk to be reloaded from.
+func (e *Entry) evictable() bool {
+	return !e.IsPinned() && e.persistedGen.Load() == e.CurrentGeneration()
+}
+
+// enforceBudget evicts entries until in-memory content fits the memory budget.
+// The protected key, typically the entry just stored or loaded, is never evicted.
+func (m *Manager) enforceBudget(protect string) {
+	budget := m.budget.Load()
+	if budget <= 0 {
+		return
+	}
+
+	m.mu.RLock()
+	policy := m.eviction
+	m.mu.RUnlock()
+
+	m.evictMu.Lock()
+	if m.lru == nil || m.lruBytes <= budget {
+		m.evictMu.Unlock()
+		return
+	}
+
+	// Free a little more than needed so the next stores don't evict again
+	target := budget - int64(float64(budget)*evictionSlack)
+
+	var victims []*lruItem
+	for _, item := range m.evictionOrder(policy) {
+		if m.lruBytes <= target {
+			break
+		}
+		if item.key == protect || !item.entry.evictable() {
+			continue
+		}
+
+		m.lruBytes -= item.size
+		m.lru.Remove(m.lruIndex[item.key])
+		delete(m.lruIndex, item.key)
+
+		// Only drop the entry if it hasn't been replaced in the meantime
+		if m.entryMap().CompareAndDelete(item.key, item.entry) {
+			victims = append(victims, item)
+		}
+	}
+	remaining := m.lruBytes
+	m.evictMu.Unlock()
+
+	for _, item := range victims {
+		// Stale entries are reloaded as stale, so they are still re-rendered
+		if item.entry.IsStale() {
+			m.persistStale(item.key, item.entry)
+		}
+		m.emit(EventEvict, item.key, item.entry.Strategy)
+	}
+
+	m.evicted.Add(int64(len(victims)))
+	m.logger.Debug("evicted cache entries from memory",
+		slog.Int("count", len(victims)),
+		slog.Int64("bytes", remaining),
+		slog.Int64("budget", budget),
+		slog.String("policy", string(policy)),
+	)
+}
+
+// evictionOrder returns tracked entries in the order they should be evicted.
+// LRU walks the access list from its back; stale-first ranks stale entries
+// first, then fresh entries by hit count. Callers must hold m.evictMu.
+func (m *Manager) evictionOrder(policy EvictionPolicy) []*lruItem {
+	items := make([]*lruItem, 0, m.lru.Len())
+	for element := m.lru.Back(); element != nil; element = element.Prev() {
+		items = append(items, element.Value.(*lruItem))
+	}
+
+	if policy == EvictStaleFirst {
+		// Stable, so ties keep least-recently-used order
+		sort.SliceStable(items, func(i, j int) bool {
+			a, b := items[i].entry, items[j].entry
+			aStale, bStale := a.IsStale(), b.IsStale()
+			if aStale != bStale {
+				return aStale
+			}
+			return !aStale && a.hits.Load() < b.hits.Load()
+		})
+	}
+
+	return items
+}
+
+// persistStale records an evicted entry's stale flag in its metadata.
+func (m *Manager) persistStale(cacheKey string, entry *Entry) {
+	meta := entry.Meta()
+	meta.ContentVersion = m.getContentVersion()
+	if err := m.storage.WriteMeta(cacheKey, meta); err != nil {
+		m.logger.Warn("failed to persist stale flag of evicted entry",
+			slog.String("key", cacheKey),
+			slog.String("error", err.Error()),
+		)
+	}
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"testing"
+)
+
+// seed stores a page per key and returns the total in-memory size.
+func seed(t *testing.T, m *Manager, strategy string, keys ...string) int64 {
+	t.Helper()
+	var total int64
+	for _, key := range keys {
+		if err := m.SetSync(key, []byte("<p>page "+key+"</p>"), strategy, "/"+key); err != nil {
+			t.Fatalf("SetSync %q: %v", key, err)
+		}
+		entry, _ := m.Get(key)
+		total += entry.memorySize()
+	}
+	return total
+}
+
+// inMemory reports whether key is held in memory, without loading it from disk.
+func inMemory(m *Manager, key string) bool {
+	_, ok := m.entryMap().Load(key)
+	return ok
+}
+
+func TestEvictLRU(t *testing.T) {
+	m := newTestManager(t)
+	total := seed(t, m, "static", "a:en", "b:en", "c:en")
+	m.Get("a:en")
+
+	m.SetMemoryBudget(total-1, EvictLRU)
+
+	if inMemory(m, "b:en") {
+		t.Error("least recently used entry b:en still in memory")
+	}
+	if !inMemory(m, "a:en") || !inMemory(m, "c:en") {
+		t.Error("recently used entries were evicted")
+	}
+	if got := m.Stats().Evicted; got != 1 {
+		t.Errorf("Evicted = %d, want 1", got)
+	}
+
+	// Evicted entries are reloaded from disk
+	if got := content(t, m, "b:en"); got != "<p>page b:en</p>" {
+		t.Errorf("reloaded content = %q", got)
+	}
+}
+
+func TestEvictStaleFirst(t *testing.T) {
+	m := newTestManager(t)
+	total := seed(t, m, "static", "hot:en", "warm:en", "cold:en")
+	for i := 0; i < 5; i++ {
+		m.Get("hot:en")
+		m.Get("warm:en")
+	}
+
+	// The hot entry goes stale; it is evicted ahead of the cold fresh one
+	hot, _ := m.Get("hot:en")
+	hot.MarkStale()
+	m.SetMemoryBudget(total-1, EvictStaleFirst)
+
+	if inMemory(m, "hot:en") {
+		t.Error("stale entry still in memory")
+	}
+	if !inMemory(m, "cold:en") || !inMemory(m, "warm:en") {
+		t.Error("fresh entries were evicted before the stale one")
+	}
+
+	// The stale flag survives the round trip through disk
+	reloaded, ok := m.Get("hot:en")
+	if !ok {
+		t.Fatal("evicted entry not reloaded from disk")
+	}
+	if !reloaded.IsStale() {
+		t.Error("evicted stale entry reloaded as fresh")
+	}
+}
+
+func TestEvictKeepsEntriesWithoutDiskCopy(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("ephemeral")
+	ephemeral := seed(t, m, "ephemeral", "memory:en")
+	persisted := seed(t, m, "static", "disk:en")
+
+	m.SetMemoryBudget((ephemeral+persisted)/4, EvictLRU)
+
+	if !inMemory(m, "memory:en") {
+		t.Error("memory-only entry evicted; it has no disk copy to reload")
+	}
+	if inMemory(m, "disk:en") {
+		t.Error("persisted entry not evicted")
+	}
+}
+
+func TestEvictTracksStores(t *testing.T) {
+	m := newTestManager(t)
+	total := seed(t, m, "static", "a:en", "b:en")
+	m.SetMemoryBudget(total, EvictLRU)
+
+	// Storing past the budget evicts the least recently used entry
+	seed(t, m, "static", "c:en")
+
+	if inMemory(m, "a:en") {
+		t.Error("least recently used entry a:en still in memory")
+	}
+	if !inMemory(m, "c:en") {
+		t.Error("newly stored entry evicted")
+	}
+
+	// Deleted entries no longer count against the budget
+	if err := m.Delete("c:en"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	var held int64
+	m.entryMap().Range(func(key, value interface{}) bool {
+		held += value.(*Entry).memorySize()
+		return true
+	})
+	m.evictMu.Lock()
+	tracked := m.lruBytes
+	m.evictMu.Unlock()
+	if tracked != held {
+		t.Errorf("tracked bytes = %d, want %d held in memory", tracked, held)
+	}
+}
+
+func TestPinnedEntrySurvivesStalenessAndEviction(t *testing.T) {
+	m := newTestManager(t)
+	seed(t, m, "static", "promo:en", "other:en")
+	if !m.Pin("promo:en") {
+		t.Fatal("Pin reported the entry missing")
+	}
+
+	m.MarkAllStale(false)
+	m.SetMemoryBudget(1, EvictLRU)
+
+	promo, ok := m.entryMap().Load("promo:en")
+	if !ok {
+		t.Fatal("pinned entry evicted")
+	}
+	if promo.(*Entry).IsStale() {
+		t.Error("pinned entry marked stale")
+	}
+	if inMemory(m, "other:en") {
+		t.Error("unpinned entry not evicted")
+	}
+
+	// Once unpinned, the entry is treated like any other
+	if !m.Unpin("promo:en") {
+		t.Fatal("Unpin reported the entry missing")
+	}
+	m.MarkAllStale(false)
+	if !promo.(*Entry).IsStale() {
+		t.Error("unpinned entry not marked stale")
+	}
+	m.SetMemoryBudget(1, EvictLRU)
+	if inMemory(m, "promo:en") {
+		t.Error("unpinned entry not evicted")
+	}
+}
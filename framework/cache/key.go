@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+// KeyOptions configures how cache keys are derived.
+type KeyOptions struct {
+	Version     string // Content/deploy version namespacing all keys, e.g. a build hash
+	StripRegion bool   // Drop region subtags from languages, e.g. "en-US" becomes "en"
+	// Salt is a server-side secret hashed into every key, so keys cannot be
+	// predicted from request input. Keys stay deterministic for a given salt.
+	Salt string
+}
+
+// keyOptions holds the process-wide key derivation settings.
+var keyOptions atomic.Pointer[KeyOptions]
+
+// SetKeyOptions configures cache key derivation for the whole process.
+// Call it once at startup, before creating the cache manager or generating any
+// keys. Changing the version on a new deploy makes every previously cached key unreachable.
+func SetKeyOptions(opts KeyOptions) {
+	keyOptions.Store(&opts)
+}
+
+// GetKeyOptions returns the current key derivation settings.
+func GetKeyOptions() KeyOptions {
+	if opts := keyOptions.Load(); opts != nil {
+		return *opts
+	}
+	return KeyOptions{}
+}
+
+// GetCacheKey generates a cache key from canonical path, language, and path params.
+// When a content version is configured, it is prefixed as "version@canonical:lang".
+// With a salt, the path portion is replaced by its salted hash ("version@hash:lang").
+// The language is normalized so "EN" and "en" share a key.
+func GetCacheKey(canonical, lang string, pathParams map[string]string) string {
+	opts := GetKeyOptions()
+	key := canonical
+
+	// Replace {param} placeholders with actual values
+	for param, value := range pathParams {
+		key = strings.ReplaceAll(key, "{"+param+"}", value)
+	}
+
+	// Hide the path behind a salted hash so keys aren't externally predictable
+	if opts.Salt != "" {
+		key = saltedHash(opts.Salt, key)
+	}
+
+	// Namespace keys by content version
+	if opts.Version != "" {
+		key = opts.Version + "@" + key
+	}
+
+	return key + ":" + normalizeLanguage(lang, opts.StripRegion)
+}
+
+// WithVariant qualifies a canonical path with a response variant, such as a
+// negotiated format, so each variant is cached under its own key.
+// "/about" with variant "json" becomes "/about~json"; an empty variant is a no-op.
+func WithVariant(canonical, variant string) string {
+	if variant == "" {
+		return canonical
+	}
+	return canonical + "~" + variant
+}
+
+// saltedHash returns a hex HMAC-SHA256 of the key under the salt, truncated
+// to 32 characters; plenty to avoid collisions while keeping file names short.
+func saltedHash(salt, key string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// normalizeLanguage lowercases a language tag, using "-" as the subtag
+// separator, and optionally drops everything after the primary subtag.
+func normalizeLanguage(lang string, stripRegion bool) string {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	if stripRegion {
+		if primary, _, found := strings.Cut(lang, "-"); found {
+			return primary
+		}
+	}
+	return lang
+}
+
+// keyLanguage extracts the language suffix from a cache key.
+func keyLanguage(cacheKey string) string {
+	if i := strings.LastIndex(cacheKey, ":"); i >= 0 {
+		return cacheKey[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCacheKeyVersion(t *testing.T) {
+	dir := t.TempDir()
+	setKeyOptions(t, KeyOptions{Version: "build-1"})
+	oldKey := GetCacheKey("/about", "en", nil)
+
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := m.SetSync(oldKey, []byte("<p>old build</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	// The next deploy namespaces keys under its own version
+	setKeyOptions(t, KeyOptions{Version: "build-2"})
+	newKey := GetCacheKey("/about", "en", nil)
+	if newKey == oldKey {
+		t.Fatalf("key %q unchanged across versions", newKey)
+	}
+
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if _, ok := restarted.Get(newKey); ok {
+		t.Error("entry of the previous version matched under the new key")
+	}
+}
+
+func TestCacheKeyLanguageNormalization(t *testing.T) {
+	tests := []struct {
+		name        string
+		stripRegion bool
+		lang        string
+		want        string
+	}{
+		{"lowercase", false, "en", "/about:en"},
+		{"uppercase", false, "EN", "/about:en"},
+		{"region kept", false, "en-US", "/about:en-us"},
+		{"underscore separator", false, "en_US", "/about:en-us"},
+		{"region stripped", true, "en-US", "/about:en"},
+		{"region stripped, uppercase", true, "PT_BR", "/about:pt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setKeyOptions(t, KeyOptions{StripRegion: tt.stripRegion})
+			if got := GetCacheKey("/about", tt.lang, nil); got != tt.want {
+				t.Errorf("GetCacheKey(%q) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheKeySalt(t *testing.T) {
+	key := func(opts KeyOptions, canonical string) string {
+		t.Helper()
+		setKeyOptions(t, opts)
+		return GetCacheKey(canonical, "en", nil)
+	}
+
+	first := key(KeyOptions{Salt: "first"}, "/about")
+	if strings.Contains(first, "about") {
+		t.Errorf("salted key %q exposes the path", first)
+	}
+	if !strings.HasSuffix(first, ":en") {
+		t.Errorf("salted key %q lost its language suffix", first)
+	}
+
+	tests := []struct {
+		name      string
+		opts      KeyOptions
+		canonical string
+		same      bool
+	}{
+		{"same salt", KeyOptions{Salt: "first"}, "/about", true},
+		{"other salt", KeyOptions{Salt: "second"}, "/about", false},
+		{"other path", KeyOptions{Salt: "first"}, "/contact", false},
+		{"no salt", KeyOptions{}, "/about", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := key(tt.opts, tt.canonical) == first; got != tt.same {
+				t.Errorf("key matches the first salt's = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	if got := key(KeyOptions{Salt: "first", Version: "v2"}, "/about"); got != "v2@"+first {
+		t.Errorf("versioned salted key = %q, want %q", got, "v2@"+first)
+	}
+}
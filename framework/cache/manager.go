@@ -1,42 +1,142 @@
 package cache
 
 import (
+	"bytes"
+	"container/list"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	fwctx "statigo/framework/context"
 )
 
 // Manager handles cache operations with memory and file storage.
 type Manager struct {
-	entries sync.Map // Thread-safe map of cache entries (key: cacheKey, value: *Entry)
-	storage *Storage
-	logger  *slog.Logger
-	router  http.Handler
-	mu      sync.RWMutex
+	entries      atomic.Pointer[sync.Map] // Cache entries (key: cacheKey, value: *Entry); swapped wholesale by ReplaceAll
+	tombstones   sync.Map                 // Keys intentionally removed (key: cacheKey, value: bool)
+	storage      *Storage
+	logger       *slog.Logger
+	router       http.Handler
+	memoryOnly   map[string]bool                      // Strategies whose entries are never written to disk
+	sampleRate   float64                              // Fraction of request-time writes persisted to disk (0 = all)
+	sampler      func() float64                       // Random source in [0, 1) for write sampling
+	subscribers  []*subscriber                        // Event channels returned by Subscribe
+	validator    func(content []byte) bool            // Rejects rendered content that must not be cached
+	ratioWarn    float64                              // Compression ratio above which a warning is logged (0 = disabled)
+	onSet        func(key, strategy string, size int) // Callback run after successful disk writes
+	minFresh     time.Duration                        // Entries younger than this are never marked stale
+	contentVer   string                               // Fingerprint of templates/translations entries are rendered with
+	diskless     bool                                 // Storage is unavailable; entries live in memory only
+	jitter       float64                              // Fraction of the incremental TTL keys are spread across
+	cacheable    map[int]bool                         // Response statuses that may be cached (nil = 200 only)
+	revalidators map[string]revalidator               // Revalidation hooks by strategy
+	mu           sync.RWMutex
+
+	revalidation   map[string]*RevalidationStats // Revalidation outcomes by strategy
+	revalidationMu sync.Mutex
+
+	renders renderGroup // Coalesces concurrent GetOrRender misses per key
+
+	droppedEvents atomic.Int64 // Events discarded for subscribers with a full buffer
+
+	warmup   WarmupProgress // Progress of the running or last Bootstrap
+	warmupMu sync.Mutex
+
+	priority   []priorityWarm // Pages queued for priority warming by the StaleWarmer
+	priorityMu sync.Mutex
+
+	budget   atomic.Int64             // Maximum in-memory content bytes (0 = unlimited)
+	eviction EvictionPolicy           // How entries are chosen when the budget is exceeded
+	lru      *list.List               // Entries by last access, most recent first; nil without a budget
+	lruIndex map[string]*list.Element // Elements of lru by key
+	lruBytes int64                    // Total content size of the entries in lru
+	evictMu  sync.Mutex               // Guards lru, lruIndex and lruBytes
+	evicted  atomic.Int64             // Entries evicted from memory
+}
+
+// ManagerConfig configures cache manager construction.
+type ManagerConfig struct {
+	// StrictStorage fails construction when the cache directory cannot be created
+	// (e.g. a read-only filesystem) instead of falling back to memory-only caching.
+	StrictStorage bool
+}
+
+// DefaultManagerConfig returns default configuration.
+func DefaultManagerConfig() ManagerConfig {
+	return ManagerConfig{
+		StrictStorage: false,
+	}
 }
 
 // NewManager creates a new cache manager.
 func NewManager(cacheDir string, logger *slog.Logger) (*Manager, error) {
+	return NewManagerWithConfig(cacheDir, DefaultManagerConfig(), logger)
+}
+
+// NewManagerWithConfig creates a cache manager with custom configuration.
+// Unless StrictStorage is set, an unusable cache directory is logged and the
+// manager caches in memory only, without writing to disk.
+func NewManagerWithConfig(cacheDir string, config ManagerConfig, logger *slog.Logger) (*Manager, error) {
 	storage, err := NewStorage(cacheDir)
+	diskless := false
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		if config.StrictStorage {
+			return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		}
+		logger.Warn("cache storage unavailable, caching in memory only",
+			slog.String("dir", cacheDir),
+			slog.String("error", err.Error()),
+		)
+		storage = newStorage(cacheDir)
+		diskless = true
 	}
 
-	return &Manager{
-		storage: storage,
-		logger:  logger,
-	}, nil
+	m := &Manager{
+		storage:   storage,
+		logger:    logger,
+		ratioWarn: defaultCompressionWarnRatio,
+		diskless:  diskless,
+		jitter:    defaultTTLJitter,
+	}
+
+	if !m.diskless {
+		if err := m.loadTombstones(); err != nil {
+			return nil, fmt.Errorf("failed to load tombstones: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// entryMap returns the current in-memory entry map, creating it on first use.
+func (m *Manager) entryMap() *sync.Map {
+	if entries := m.entries.Load(); entries != nil {
+		return entries
+	}
+	m.entries.CompareAndSwap(nil, new(sync.Map))
+	return m.entries.Load()
 }
 
 // Get retrieves a cache entry from memory or disk.
+// Tombstoned keys are never returned; use IsTombstoned to detect them.
 func (m *Manager) Get(cacheKey string) (*Entry, bool) {
+	if m.IsTombstoned(cacheKey) {
+		return nil, false
+	}
+
 	// Try memory cache first
-	if entry, ok := m.entries.Load(cacheKey); ok {
-		return entry.(*Entry), true
+	if value, ok := m.entryMap().Load(cacheKey); ok {
+		entry := value.(*Entry)
+		entry.recordAccess()
+		m.trackEntry(cacheKey, entry)
+		return entry, true
 	}
 
 	// Try loading from disk
@@ -51,7 +151,10 @@ func (m *Manager) Get(cacheKey string) (*Entry, bool) {
 		}
 
 		// Store in memory for faster subsequent access
-		m.entries.Store(cacheKey, entry)
+		entry.recordAccess()
+		m.entryMap().Store(cacheKey, entry)
+		m.trackEntry(cacheKey, entry)
+		m.enforceBudget(cacheKey)
 		return entry, true
 	}
 
@@ -64,12 +167,103 @@ func (m *Manager) Set(cacheKey string, uncompressedContent []byte, strategy, req
 }
 
 // SetSync stores a cache entry in memory and disk synchronously.
+// Unlike Set, disk write failures are returned to the caller.
 func (m *Manager) SetSync(cacheKey string, uncompressedContent []byte, strategy, requestPath string) error {
 	return m.set(cacheKey, uncompressedContent, strategy, requestPath, true)
 }
 
-// set is the internal method that handles cache storage.
+// SetWithContentType stores a page together with the Content-Type it must be
+// served with (e.g. a JSON variant); empty means HTML. The type is persisted in the sidecar.
+func (m *Manager) SetWithContentType(cacheKey string, uncompressedContent []byte, contentType, strategy, requestPath string) error {
+	return m.setPage(cacheKey, uncompressedContent, strategy, revision{requestPath: requestPath, contentType: contentType}, false)
+}
+
+// SetWithStatus stores a page that is replayed with the given status (e.g. a
+// cached 404) and Content-Type. The status is persisted in the sidecar.
+func (m *Manager) SetWithStatus(cacheKey string, uncompressedContent []byte, status int, contentType, strategy, requestPath string) error {
+	if status == http.StatusOK {
+		status = 0
+	}
+	return m.setPage(cacheKey, uncompressedContent, strategy, revision{requestPath: requestPath, status: status, contentType: contentType}, false)
+}
+
+// SetCacheableStatuses sets the response statuses pages may be cached with,
+// both at request time and when warming. The default is 200 only.
+func (m *Manager) SetCacheableStatuses(statuses ...int) {
+	cacheable := make(map[int]bool, len(statuses))
+	for _, status := range statuses {
+		cacheable[status] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheable = cacheable
+}
+
+// IsCacheableStatus reports whether a page rendered with the status may be cached.
+// Redirects are cached separately via SetRedirect.
+func (m *Manager) IsCacheableStatus(status int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cacheable == nil {
+		return status == http.StatusOK
+	}
+	return m.cacheable[status]
+}
+
+// SetRedirect stores a permanent redirect (301 or 308) so it can be replayed
+// without invoking the handler. The status and location are persisted in the sidecar.
+func (m *Manager) SetRedirect(cacheKey string, status int, location, strategy, requestPath string) error {
+	if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+		return fmt.Errorf("unsupported redirect status: %d", status)
+	}
+	if location == "" {
+		return fmt.Errorf("redirect location is empty")
+	}
+
+	return m.store(cacheKey, nil, strategy, revision{requestPath: requestPath, status: status, location: location}, false)
+}
+
+// CompareAndSet stores a page only if the entry is still at expectedGeneration
+// (0 meaning the key must not be cached yet), so a slow re-render never
+// overwrites a newer one. Returns false when another writer got there first.
+func (m *Manager) CompareAndSet(cacheKey string, expectedGeneration int64, uncompressedContent []byte, strategy, requestPath string) (bool, error) {
+	rev := revision{requestPath: requestPath, conditional: true, expectGeneration: expectedGeneration}
+	if err := m.setPage(cacheKey, uncompressedContent, strategy, rev, false); err != nil {
+		if errors.Is(err, ErrConflict) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// set is the internal method that handles page storage.
 func (m *Manager) set(cacheKey string, uncompressedContent []byte, strategy, requestPath string, sync bool) error {
+	return m.setPage(cacheKey, uncompressedContent, strategy, revision{requestPath: requestPath}, sync)
+}
+
+// setPage stores a page. Content rejected by the configured validator is not cached.
+func (m *Manager) setPage(cacheKey string, uncompressedContent []byte, strategy string, rev revision, sync bool) error {
+	if !m.validContent(uncompressedContent) {
+		m.logger.Warn("rejected invalid content, skipping cache write",
+			slog.String("key", cacheKey),
+			slog.String("request_path", rev.requestPath),
+		)
+		return newError("validate content", cacheKey, ErrRejected, ErrRejected)
+	}
+
+	return m.store(cacheKey, uncompressedContent, strategy, rev, sync)
+}
+
+// store handles cache storage for pages and redirects.
+func (m *Manager) store(cacheKey string, uncompressedContent []byte, strategy string, rev revision, sync bool) error {
+	// Intentionally removed pages are never cached again
+	if m.IsTombstoned(cacheKey) {
+		return newError("store cache entry", cacheKey, ErrGone, ErrGone)
+	}
+
 	// Compress content for memory storage
 	compressedContent, err := CompressBrotli(uncompressedContent)
 	if err != nil {
@@ -81,44 +275,185 @@ func (m *Manager) set(cacheKey string, uncompressedContent []byte, strategy, req
 		compressedContent = uncompressedContent
 	}
 
-	// Check if entry exists and update it, or create new one
-	if existingValue, exists := m.entries.Load(cacheKey); exists {
+	rev.content = compressedContent
+	if len(uncompressedContent) > 0 {
+		rev.compressionRatio = float64(len(compressedContent)) / float64(len(uncompressedContent))
+		m.checkCompressionRatio(cacheKey, len(uncompressedContent), rev.compressionRatio)
+	}
+
+	// Keep the original in memory when brotli didn't make it smaller
+	if err != nil || len(compressedContent) >= len(uncompressedContent) {
+		rev.content = uncompressedContent
+		rev.uncompressed = true
+	}
+
+	// Create a new entry, or update the existing one if another writer got there first
+	var entry *Entry
+	var generation int64
+	hooks := m.revalidatorFor(strategy)
+	if hooks.version != nil {
+		rev.dataVersion = hooks.version(rev.requestPath)
+	}
+	newEntry := NewEntry(rev.content, strategy, rev.requestPath)
+	newEntry.Status = rev.status
+	newEntry.Location = rev.location
+	newEntry.CompressionRatio = rev.compressionRatio
+	newEntry.ContentType = rev.contentType
+	newEntry.Uncompressed = rev.uncompressed
+	newEntry.DataVersion = rev.dataVersion
+	newEntry.ttlJitter = m.ttlJitter(cacheKey)
+	newEntry.setRevalidateFunc(hooks.check)
+	var existingValue interface{}
+	var loaded bool
+	if rev.conditional && rev.expectGeneration != 0 {
+		// Compare-and-set against an existing entry never creates one
+		if existingValue, loaded = m.entryMap().Load(cacheKey); !loaded {
+			return newError("compare and set", cacheKey, ErrConflict, ErrConflict)
+		}
+	} else {
+		existingValue, loaded = m.entryMap().LoadOrStore(cacheKey, newEntry)
+	}
+	if loaded {
 		// Update existing entry
-		existingEntry := existingValue.(*Entry)
-		existingEntry.Update(compressedContent, requestPath)
+		entry = existingValue.(*Entry)
+		if generation = entry.update(rev); generation == 0 {
+			return newError("compare and set", cacheKey, ErrConflict, ErrConflict)
+		}
 
 		m.logger.Debug("cache updated",
 			slog.String("key", cacheKey),
 			slog.String("strategy", strategy),
-			slog.String("request_path", requestPath),
-			slog.Int64("generation", existingEntry.Generation),
+			slog.String("request_path", rev.requestPath),
+			slog.Int64("generation", generation),
 		)
 	} else {
-		// Create new cache entry
-		entry := NewEntry(compressedContent, strategy, requestPath)
-		m.entries.Store(cacheKey, entry)
+		entry = newEntry
+		generation = newEntry.Generation
+		entry.lastAccess.Store(time.Now().UnixNano())
 
 		m.logger.Debug("cache created",
 			slog.String("key", cacheKey),
 			slog.String("strategy", strategy),
-			slog.String("request_path", requestPath),
+			slog.String("request_path", rev.requestPath),
 		)
 	}
 
-	// Write to disk
-	writeFunc := func() {
+	m.emit(EventSet, cacheKey, strategy)
+	m.trackEntry(cacheKey, entry)
+	m.enforceBudget(cacheKey)
+
+	// Write to disk, skipping writes already superseded by a newer generation
+	writeFunc := func() error {
+		entry.writeMu.Lock()
+		defer entry.writeMu.Unlock()
+
+		if entry.CurrentGeneration() != generation {
+			m.logger.Debug("skipping superseded cache write",
+				slog.String("key", cacheKey),
+				slog.Int64("generation", generation),
+			)
+			return nil
+		}
+
+		// Request-time writes may be sampled; synchronous warm-up writes always persist
+		if !sync && !m.sampleWrite() {
+			m.logger.Debug("skipping unsampled cache write",
+				slog.String("key", cacheKey),
+			)
+			return nil
+		}
+
+		// Nothing persists without usable storage
+		if m.diskless {
+			return nil
+		}
+
+		// Memory-only strategies never persist; drop files left by an earlier strategy
+		if m.isMemoryOnly(strategy) {
+			if err := m.storage.Delete(cacheKey); err != nil {
+				m.logger.Warn("failed to remove disk cache for memory-only entry",
+					slog.String("key", cacheKey),
+					slog.String("error", err.Error()),
+				)
+			}
+			return nil
+		}
+
 		if err := m.storage.Write(cacheKey, compressedContent, uncompressedContent); err != nil {
 			m.logger.Error("failed to write cache to disk",
 				slog.String("key", cacheKey),
 				slog.String("error", err.Error()),
 			)
+			return err
+		}
+
+		meta := entry.Meta()
+		meta.ContentVersion = m.getContentVersion()
+		if err := m.storage.WriteMeta(cacheKey, meta); err != nil {
+			m.logger.Error("failed to write cache metadata to disk",
+				slog.String("key", cacheKey),
+				slog.String("error", err.Error()),
+			)
+			return err
 		}
+
+		entry.persistedGen.Store(generation)
+		m.notifySet(cacheKey, strategy, len(uncompressedContent))
+		return nil
 	}
 
+	// Synchronous writes report disk failures to the caller
 	if sync {
-		writeFunc()
-	} else {
-		go writeFunc()
+		return writeFunc()
+	}
+	go writeFunc()
+
+	return nil
+}
+
+// ReplaceAll atomically swaps the in-memory entries for the given set, e.g. after
+// an import or a staged rebuild. Readers see either the previous or the new set,
+// never a mix. Keys only in the previous set are dropped; with pruneDisk their
+// files are deleted as well, otherwise a later Get may load them from disk again.
+func (m *Manager) ReplaceAll(entries map[string]*Entry, pruneDisk bool) error {
+	next := new(sync.Map)
+	for key, entry := range entries {
+		if entry != nil {
+			next.Store(key, entry)
+		}
+	}
+
+	m.mu.Lock()
+	previous := m.entryMap()
+	m.entries.Store(next)
+	m.mu.Unlock()
+
+	m.evictMu.Lock()
+	m.resetLRU()
+	m.evictMu.Unlock()
+
+	var removed []string
+	previous.Range(func(key, value interface{}) bool {
+		if _, ok := next.Load(key); !ok {
+			removed = append(removed, key.(string))
+			m.emit(EventDelete, key.(string), value.(*Entry).Strategy)
+		}
+		return true
+	})
+
+	m.logger.Info("cache entries replaced",
+		slog.Int("entries", len(entries)),
+		slog.Int("removed", len(removed)),
+	)
+
+	if !pruneDisk || m.diskless {
+		return nil
+	}
+
+	for _, key := range removed {
+		if err := m.storage.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete cache from disk: %w", err)
+		}
 	}
 
 	return nil
@@ -126,7 +461,10 @@ func (m *Manager) set(cacheKey string, uncompressedContent []byte, strategy, req
 
 // Delete removes a cache entry from memory and disk.
 func (m *Manager) Delete(cacheKey string) error {
-	m.entries.Delete(cacheKey)
+	if value, ok := m.entryMap().LoadAndDelete(cacheKey); ok {
+		m.untrackEntry(cacheKey)
+		m.emit(EventDelete, cacheKey, value.(*Entry).Strategy)
+	}
 
 	if err := m.storage.Delete(cacheKey); err != nil {
 		return fmt.Errorf("failed to delete cache from disk: %w", err)
@@ -136,23 +474,26 @@ func (m *Manager) Delete(cacheKey string) error {
 }
 
 // MarkStale marks cache entries matching the strategy as stale.
+// Immutable, pinned and freshly rendered entries (see SetMinFreshAge) are skipped.
 func (m *Manager) MarkStale(strategy string, eager bool) int {
+	minFreshAge := m.getMinFreshAge()
 	count := 0
-	var staleEntries []*Entry
+	var staleEntries []keyedEntry
 
-	m.entries.Range(func(key, value interface{}) bool {
+	m.entryMap().Range(func(key, value interface{}) bool {
 		entry := value.(*Entry)
 
-		if entry.Strategy == "immutable" {
+		if entry.Strategy == "immutable" || entry.IsPinned() || entry.Age() < minFreshAge {
 			return true
 		}
 
 		if entry.Strategy == strategy {
 			entry.MarkStale()
 			count++
+			m.emit(EventMarkStale, key.(string), entry.Strategy)
 
 			if eager {
-				staleEntries = append(staleEntries, entry)
+				staleEntries = append(staleEntries, keyedEntry{key: key.(string), entry: entry})
 			}
 
 			m.logger.Debug("marked cache as stale",
@@ -164,6 +505,8 @@ func (m *Manager) MarkStale(strategy string, eager bool) int {
 		return true
 	})
 
+	m.recordMarkedStale(strategy, count)
+
 	m.logger.Info("marked caches as stale",
 		slog.String("strategy", strategy),
 		slog.Int("count", count),
@@ -171,56 +514,143 @@ func (m *Manager) MarkStale(strategy string, eager bool) int {
 	)
 
 	if eager && len(staleEntries) > 0 {
-		go m.eagerRevalidate(staleEntries)
+		go m.eagerRevalidate(staleEntries, defaultRevalidateConcurrency)
 	}
 
 	return count
 }
 
-// MarkAllStale marks all cache entries as stale (except immutable).
+// MarkAllStale marks all cache entries as stale (except immutable, pinned and freshly rendered).
 func (m *Manager) MarkAllStale(eager bool) int {
+	minFreshAge := m.getMinFreshAge()
 	count := 0
-	var staleEntries []*Entry
+	countByStrategy := make(map[string]int)
+	var staleEntries []keyedEntry
 
-	m.entries.Range(func(key, value interface{}) bool {
+	m.entryMap().Range(func(key, value interface{}) bool {
 		entry := value.(*Entry)
 
-		if entry.Strategy == "immutable" {
+		if entry.Strategy == "immutable" || entry.IsPinned() || entry.Age() < minFreshAge {
 			return true
 		}
 
 		entry.MarkStale()
 		count++
+		countByStrategy[entry.Strategy]++
+		m.emit(EventMarkStale, key.(string), entry.Strategy)
 
 		if eager {
-			staleEntries = append(staleEntries, entry)
+			staleEntries = append(staleEntries, keyedEntry{key: key.(string), entry: entry})
 		}
 
 		return true
 	})
 
+	for strategy, n := range countByStrategy {
+		m.recordMarkedStale(strategy, n)
+	}
+
 	m.logger.Info("marked all caches as stale",
 		slog.Int("count", count),
 		slog.Bool("eager", eager),
 	)
 
 	if eager && len(staleEntries) > 0 {
-		go m.eagerRevalidate(staleEntries)
+		go m.eagerRevalidate(staleEntries, defaultRevalidateConcurrency)
 	}
 
 	return count
 }
 
-// GetCacheKey generates a cache key from canonical path, language, and path params.
-func GetCacheKey(canonical, lang string, pathParams map[string]string) string {
-	key := canonical
+// SetContentVersion records the fingerprint of the templates and translations pages
+// are rendered with. Entries persisted under a different version are loaded as stale,
+// and changing the version at runtime (e.g. from a file watcher) marks all entries
+// stale. Returns the number of entries marked stale.
+func (m *Manager) SetContentVersion(version string) int {
+	m.mu.Lock()
+	previous := m.contentVer
+	m.contentVer = version
+	m.mu.Unlock()
+
+	if previous == "" || previous == version {
+		return 0
+	}
+
+	m.logger.Info("templates or translations changed, invalidating cache",
+		slog.String("previous_version", previous),
+		slog.String("version", version),
+	)
+	return m.MarkAllStale(false)
+}
+
+// getContentVersion returns the current content fingerprint.
+func (m *Manager) getContentVersion() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.contentVer
+}
+
+// SetTTLJitter spreads incremental expirations by a deterministic per-key offset
+// of up to ±fraction of the TTL (default 0.1). Zero disables jitter.
+// Applies to entries created or loaded afterwards.
+func (m *Manager) SetTTLJitter(fraction float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jitter = fraction
+}
+
+// ttlJitter returns the TTL offset for a key under the configured jitter.
+func (m *Manager) ttlJitter(cacheKey string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return ttlJitter(cacheKey, m.jitter)
+}
+
+// SetMinFreshAge sets a grace period during which a newly rendered entry cannot be
+// marked stale, so repeated invalidations (e.g. a deploy hook firing twice) do not
+// trigger back-to-back re-renders. Zero disables the grace period.
+func (m *Manager) SetMinFreshAge(age time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.minFresh = age
+}
 
-	// Replace {param} placeholders with actual values
-	for param, value := range pathParams {
-		key = strings.ReplaceAll(key, "{"+param+"}", value)
+// getMinFreshAge returns the configured stale grace period.
+func (m *Manager) getMinFreshAge() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.minFresh
+}
+
+// Pin protects an entry from MarkStale, MarkAllStale and eviction until Unpin,
+// regardless of its strategy. Pins are held in memory only.
+// Returns false if the key is not cached.
+func (m *Manager) Pin(cacheKey string) bool {
+	entry, ok := m.Get(cacheKey)
+	if !ok {
+		return false
 	}
 
-	return key + ":" + lang
+	entry.pinned.Store(true)
+	m.logger.Info("cache entry pinned",
+		slog.String("key", cacheKey),
+	)
+	return true
+}
+
+// Unpin makes a pinned entry eligible for staleness and eviction again.
+// Returns false if the key is not cached.
+func (m *Manager) Unpin(cacheKey string) bool {
+	value, ok := m.entryMap().Load(cacheKey)
+	if !ok {
+		return false
+	}
+
+	value.(*Entry).pinned.Store(false)
+	m.logger.Info("cache entry unpinned",
+		slog.String("key", cacheKey),
+	)
+	return true
 }
 
 // SetRouter sets the HTTP router for eager revalidation.
@@ -230,9 +660,143 @@ func (m *Manager) SetRouter(router http.Handler) {
 	m.router = router
 }
 
+// Persistent reports whether entries are written to disk, i.e. the manager
+// did not fall back to memory-only caching.
+func (m *Manager) Persistent() bool {
+	return !m.diskless
+}
+
+// SetLanguageDirectories enables per-language subdirectories in disk storage.
+// Call it before the cache is populated; existing flat files are not moved.
+func (m *Manager) SetLanguageDirectories(enabled bool) {
+	m.storage.SetLanguageDirectories(enabled)
+}
+
+// SetMetadataIndex stores entry metadata in a single index file instead of one
+// sidecar per entry. Call it before the cache is populated.
+func (m *Manager) SetMetadataIndex(enabled bool) error {
+	if m.diskless {
+		return nil
+	}
+	return m.storage.SetMetadataIndex(enabled)
+}
+
+// SetWriteRetry configures how disk writes failing with transient errors are retried.
+func (m *Manager) SetWriteRetry(config WriteRetryConfig) {
+	m.storage.SetWriteRetry(config)
+}
+
+// SetMemoryOnlyStrategies configures strategies whose entries are kept in memory
+// but never written to disk, e.g. a short-lived "ephemeral" strategy.
+func (m *Manager) SetMemoryOnlyStrategies(strategies ...string) {
+	memoryOnly := make(map[string]bool, len(strategies))
+	for _, strategy := range strategies {
+		memoryOnly[strategy] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memoryOnly = memoryOnly
+}
+
+// isMemoryOnly reports whether entries of the strategy skip disk persistence.
+func (m *Manager) isMemoryOnly(strategy string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.memoryOnly[strategy]
+}
+
+// SetOnSet registers a callback invoked after an entry is successfully written
+// to disk, e.g. to purge a CDN. size is the uncompressed content length.
+// The callback runs on the writing goroutine; a panic in it is recovered and logged.
+func (m *Manager) SetOnSet(callback func(key, strategy string, size int)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onSet = callback
+}
+
+// notifySet invokes the OnSet callback, recovering from panics.
+func (m *Manager) notifySet(cacheKey, strategy string, size int) {
+	m.mu.RLock()
+	callback := m.onSet
+	m.mu.RUnlock()
+
+	if callback == nil {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("cache OnSet callback panicked",
+				slog.String("key", cacheKey),
+				slog.Any("panic", r),
+			)
+		}
+	}()
+
+	callback(cacheKey, strategy, size)
+}
+
+// SetContentValidator configures a check applied to rendered pages before they are
+// cached, e.g. to reject a 200 response that rendered an error template.
+// Rejected content is still served but never stored; nil disables validation.
+func (m *Manager) SetContentValidator(validator func(content []byte) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validator = validator
+}
+
+// validContent reports whether content passes the configured validator.
+func (m *Manager) validContent(content []byte) bool {
+	m.mu.RLock()
+	validator := m.validator
+	m.mu.RUnlock()
+
+	return validator == nil || validator(content)
+}
+
+// RejectMarkers returns a content validator rejecting pages that contain any of the markers.
+func RejectMarkers(markers ...string) func(content []byte) bool {
+	return func(content []byte) bool {
+		for _, marker := range markers {
+			if bytes.Contains(content, []byte(marker)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SetWriteSampling persists only a fraction of request-time (Set) writes to disk,
+// while every entry stays in memory. Unsampled entries re-render after a restart.
+// A rate of 0 or >= 1 persists every write. The sampler returns values in [0, 1);
+// nil uses math/rand.
+func (m *Manager) SetWriteSampling(rate float64, sampler func() float64) {
+	if sampler == nil {
+		sampler = rand.Float64
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sampleRate = rate
+	m.sampler = sampler
+}
+
+// sampleWrite reports whether a request-time write should be persisted.
+func (m *Manager) sampleWrite() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.sampleRate <= 0 || m.sampleRate >= 1 {
+		return true
+	}
+	return m.sampler() < m.sampleRate
+}
+
 // GetDecompressedContent decompresses and returns the cached HTML content.
 func GetDecompressedContent(entry *Entry) ([]byte, error) {
-	return DecompressBrotli(entry.Content)
+	content, _, _, err := entry.DecompressedSnapshot()
+	return content, err
 }
 
 // loadFromDisk loads a cache entry from disk.
@@ -242,16 +806,63 @@ func (m *Manager) loadFromDisk(cacheKey string) (*Entry, error) {
 		return nil, fmt.Errorf("failed to read brotli cache: %w", err)
 	}
 
-	renderedAt := time.Now()
+	// Without a sidecar, date the entry by its file so the ETag stays stable across restarts
+	renderedAt, err := m.storage.ModTime(cacheKey)
+	if err != nil {
+		renderedAt = time.Now()
+	}
+
 	entry := &Entry{
 		Content:    compressedContent,
 		RenderedAt: renderedAt,
 		Strategy:   "static",
 		ETag:       generateETag(compressedContent, 1, renderedAt),
 		Generation: 1,
+		ttlJitter:  m.ttlJitter(cacheKey),
 	}
 	entry.stale.Store(false)
 
+	// Restore persisted metadata; entries without a sidecar keep the defaults above
+	meta, err := m.storage.ReadMeta(cacheKey)
+	if err == nil {
+		entry.RenderedAt = meta.RenderedAt
+		entry.Strategy = meta.Strategy
+		entry.ETag = meta.ETag
+		entry.Generation = meta.Generation
+		entry.RequestPath = meta.RequestPath
+		entry.Status = meta.Status
+		entry.Location = meta.Location
+		entry.CompressionRatio = meta.CompressionRatio
+		entry.ContentType = meta.ContentType
+		entry.DataVersion = meta.DataVersion
+
+		// Incompressible pages are kept in memory as the original HTML
+		if meta.Uncompressed {
+			if html, err := m.storage.ReadHTML(cacheKey); err == nil {
+				entry.Content = html
+				entry.Uncompressed = true
+			}
+		}
+
+		// Entries rendered with other templates/translations must be re-rendered
+		if version := m.getContentVersion(); version != "" && meta.ContentVersion != version && entry.Strategy != "immutable" {
+			entry.MarkStale()
+		}
+
+		// Entries evicted while stale are still due for a re-render
+		if meta.Stale {
+			entry.MarkStale()
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		m.logger.Warn("ignoring unreadable cache metadata",
+			slog.String("key", cacheKey),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	entry.setRevalidateFunc(m.revalidatorFor(entry.Strategy).check)
+	entry.persistedGen.Store(entry.Generation)
+
 	m.logger.Debug("loaded cache from disk",
 		slog.String("key", cacheKey),
 	)
@@ -259,8 +870,18 @@ func (m *Manager) loadFromDisk(cacheKey string) (*Entry, error) {
 	return entry, nil
 }
 
-// eagerRevalidate re-renders all stale entries in the background.
-func (m *Manager) eagerRevalidate(entries []*Entry) {
+// defaultRevalidateConcurrency is the number of concurrent eager re-renders.
+const defaultRevalidateConcurrency = 10
+
+// keyedEntry is an entry together with the key it is cached under.
+type keyedEntry struct {
+	key   string
+	entry *Entry
+}
+
+// eagerRevalidate re-renders the given entries with limited concurrency,
+// in a deterministic order (see strategyPriority).
+func (m *Manager) eagerRevalidate(entries []keyedEntry, concurrency int) {
 	m.mu.RLock()
 	router := m.router
 	m.mu.RUnlock()
@@ -275,45 +896,136 @@ func (m *Manager) eagerRevalidate(entries []*Entry) {
 	)
 
 	start := time.Now()
-	successCount := 0
-	errorCount := 0
+	var successCount, errorCount atomic.Int32
 
-	// Process entries with limited concurrency
-	semaphore := make(chan struct{}, 10)
-	var wg sync.WaitGroup
+	// Revalidate in a stable order: by strategy priority, then request path
+	type revalidation struct {
+		keyedEntry
+		requestPath string
+		strategy    string
+		etag        string
+		generation  int64
+	}
+	var pending []revalidation
+	for _, target := range entries {
+		entry := target.entry
+		entry.mu.RLock()
+		requestPath := entry.RequestPath
+		etag := entry.ETag
+		generation := entry.Generation
+		entry.mu.RUnlock()
 
-	for _, entry := range entries {
-		if entry.RequestPath == "" {
+		if requestPath == "" {
 			m.logger.Warn("skipping entry with empty request path")
 			continue
 		}
+		pending = append(pending, revalidation{
+			keyedEntry:  target,
+			requestPath: requestPath,
+			strategy:    entry.Strategy,
+			etag:        etag,
+			generation:  generation,
+		})
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		pi, pj := strategyPriority(pending[i].strategy), strategyPriority(pending[j].strategy)
+		if pi != pj {
+			return pi < pj
+		}
+		return pending[i].requestPath < pending[j].requestPath
+	})
+
+	// Process entries with limited concurrency; a goroutine is only started
+	// once a slot frees up, so at most concurrency run at a time
+	if concurrency <= 0 {
+		concurrency = defaultRevalidateConcurrency
+	}
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
 
+	for _, task := range pending {
+		semaphore <- struct{}{}
 		wg.Add(1)
-		go func(reqPath string) {
+		go func(task revalidation) {
 			defer wg.Done()
-
-			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			req := httptest.NewRequest(http.MethodGet, reqPath, nil)
-			rec := httptest.NewRecorder()
+			// Handlers that support conditional rendering may answer 304
+			// when the content behind the stored ETag is unchanged
+			req := httptest.NewRequest(http.MethodGet, task.requestPath, nil)
+			req = req.WithContext(fwctx.MarkRevalidationRequest(req.Context()))
+			if task.etag != "" {
+				req.Header.Set("If-None-Match", `"`+task.etag+`"`)
+			}
+			rec := AcquireRecorder(nil)
+			defer ReleaseRecorder(rec)
 
 			router.ServeHTTP(rec, req)
 
-			if rec.Code == http.StatusOK {
-				successCount++
+			// Stale entries are never answered from the cache, so a 304 for an entry
+			// still stale at the same generation came from the renderer itself
+			if rec.StatusCode() == http.StatusNotModified {
+				if task.entry.IsStale() && task.entry.CurrentGeneration() == task.generation {
+					m.markNotModified(task.key, task.entry)
+				}
+				successCount.Add(1)
+				m.recordRerender(task.strategy, true)
+				return
+			}
+
+			succeeded := m.IsCacheableStatus(rec.StatusCode())
+			if succeeded {
+				successCount.Add(1)
 			} else {
-				errorCount++
+				errorCount.Add(1)
 			}
-		}(entry.RequestPath)
+			m.recordRerender(task.strategy, succeeded)
+		}(task)
 	}
 
 	wg.Wait()
 
 	m.logger.Info("eager revalidation completed",
 		slog.Int("total", len(entries)),
-		slog.Int("success", successCount),
-		slog.Int("errors", errorCount),
+		slog.Int("success", int(successCount.Load())),
+		slog.Int("errors", int(errorCount.Load())),
 		slog.Duration("duration", time.Since(start)),
 	)
 }
+
+// markNotModified refreshes an entry whose revalidation was answered with 304,
+// keeping its content and ETag, and persists the new render time.
+func (m *Manager) markNotModified(cacheKey string, entry *Entry) {
+	entry.Touch()
+
+	if m.diskless {
+		return
+	}
+
+	// Don't write metadata for an entry deleted or replaced in the meantime
+	if current, ok := m.entryMap().Load(cacheKey); !ok || current.(*Entry) != entry {
+		return
+	}
+
+	meta := entry.Meta()
+	meta.ContentVersion = m.getContentVersion()
+	if err := m.storage.WriteMeta(cacheKey, meta); err != nil {
+		m.logger.Warn("failed to persist revalidated cache metadata",
+			slog.String("key", cacheKey),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// strategyPriority orders strategies for eager revalidation; pages that
+// change most often are re-rendered first.
+func strategyPriority(strategy string) int {
+	switch strategy {
+	case "incremental":
+		return 0
+	case "static":
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,612 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestManager returns a manager backed by a fresh temporary directory.
+// Tests should write with SetSync, so no background write outlives the directory.
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m, err := NewManager(t.TempDir(), discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+// testRebuildConfig returns a rebuild configuration for the given routes,
+// rendering every page through router.
+func testRebuildConfig(t *testing.T, router http.Handler, languages []string, routes ...RouteConfig) RebuildConfig {
+	t.Helper()
+	data, err := json.Marshal(map[string][]RouteConfig{"routes": routes})
+	if err != nil {
+		t.Fatalf("marshal routes: %v", err)
+	}
+	return RebuildConfig{
+		ConfigFS:   fstest.MapFS{"routes.json": {Data: data}},
+		RoutesFile: "routes.json",
+		Languages:  languages,
+		Router:     router,
+		Logger:     discardLogger,
+	}
+}
+
+// pageRouter serves body as HTML for every path.
+func pageRouter(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, body)
+	})
+}
+
+// content returns the decompressed content of the entry stored under key.
+func content(t *testing.T, m *Manager, key string) string {
+	t.Helper()
+	entry, ok := m.Get(key)
+	if !ok {
+		t.Fatalf("entry %q not found", key)
+	}
+	body, err := GetDecompressedContent(entry)
+	if err != nil {
+		t.Fatalf("decompress %q: %v", key, err)
+	}
+	return string(body)
+}
+
+// notModifiedRouter answers every request with 304 Not Modified.
+func notModifiedRouter(requests *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+	})
+}
+
+func TestEagerRevalidateNotModifiedKeepsContent(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	entry, _ := m.Get("/about:en")
+	_, etag, generation := entry.Snapshot()
+	age(t, m, "/about:en", time.Hour)
+	entry.MarkStale()
+
+	// A conditional-aware handler confirms the stored ETag is current
+	var ifNoneMatch string
+	m.SetRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = r.Header.Get("If-None-Match")
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	m.eagerRevalidate([]keyedEntry{{key: "/about:en", entry: entry}}, 1)
+
+	if ifNoneMatch != `"`+etag+`"` {
+		t.Errorf("If-None-Match = %q, want the stored ETag", ifNoneMatch)
+	}
+	if entry.IsStale() {
+		t.Error("entry still stale after 304")
+	}
+	if entry.Age() > time.Minute {
+		t.Errorf("entry age = %v, want render time refreshed", entry.Age())
+	}
+	if _, gotETag, gotGeneration := entry.Snapshot(); gotETag != etag || gotGeneration != generation {
+		t.Errorf("ETag/generation = %q/%d, want unchanged %q/%d", gotETag, gotGeneration, etag, generation)
+	}
+	meta, err := m.storage.ReadMeta("/about:en")
+	if err != nil || meta.Generation != generation || time.Since(meta.RenderedAt) > time.Minute {
+		t.Errorf("persisted meta = %+v, %v; want refreshed render time at generation %d", meta, err, generation)
+	}
+}
+
+func TestEagerRevalidateIgnoresNotModifiedForFreshEntries(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetSync("/news:en", []byte("<p>news</p>"), "incremental", "/news"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	entry, _ := m.Get("/news:en")
+	age(t, m, "/news:en", 48*time.Hour)
+
+	// A 304 for an entry that was never marked stale came from a cache HIT,
+	// not the renderer, so it must not extend the entry's life
+	var requests atomic.Int32
+	m.SetRouter(notModifiedRouter(&requests))
+	m.eagerRevalidate([]keyedEntry{{key: "/news:en", entry: entry}}, 1)
+
+	if requests.Load() != 1 {
+		t.Fatalf("requests = %d, want 1", requests.Load())
+	}
+	if !entry.ShouldRevalidate() {
+		t.Error("expired entry refreshed by a 304 that never reached the renderer")
+	}
+}
+
+func TestConcurrentSetKeepsNewestContent(t *testing.T) {
+	m := newTestManager(t)
+	const writers = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := []byte(fmt.Sprintf("<p>render %d</p>", i))
+			if err := m.SetSync("/hot:en", body, "static", "/hot"); err != nil {
+				t.Errorf("SetSync: %v", err)
+			}
+			// Readers see a consistent snapshot while writers race
+			if entry, ok := m.Get("/hot:en"); ok {
+				if _, err := GetDecompressedContent(entry); err != nil {
+					t.Errorf("decompress: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entry, _ := m.Get("/hot:en")
+	if got := entry.CurrentGeneration(); got != writers {
+		t.Errorf("generation = %d, want %d", got, writers)
+	}
+
+	// The disk holds the newest generation, never an older render
+	memory := content(t, m, "/hot:en")
+	disk, err := m.storage.ReadHTML("/hot:en")
+	if err != nil {
+		t.Fatalf("ReadHTML: %v", err)
+	}
+	if string(disk) != memory {
+		t.Errorf("disk content = %q, want %q from memory", disk, memory)
+	}
+	meta, err := m.storage.ReadMeta("/hot:en")
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if _, etag, _ := entry.Snapshot(); meta.ETag != etag || meta.Generation != writers {
+		t.Errorf("meta = %s/%d, want %s/%d", meta.ETag, meta.Generation, etag, writers)
+	}
+}
+
+// cacheFiles lists the files under dir.
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", dir, err)
+	}
+	return files
+}
+
+func TestMemoryOnlyStrategy(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetMemoryOnlyStrategies("ephemeral")
+
+	if err := m.SetSync("/preview:en", []byte("<p>preview</p>"), "ephemeral", "/preview"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	if got := content(t, m, "/preview:en"); got != "<p>preview</p>" {
+		t.Errorf("content = %q, want the stored page", got)
+	}
+	if files := cacheFiles(t, dir); len(files) != 0 {
+		t.Errorf("memory-only entry wrote files: %v", files)
+	}
+}
+
+func TestWriteSampling(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	// Deterministic sampler cycling through 0.0, 0.1, ..., 0.9
+	var draws atomic.Int64
+	m.SetWriteSampling(0.3, func() float64 {
+		return float64((draws.Add(1)-1)%10) / 10
+	})
+	persisted := make(chan string, 100)
+	m.SetOnSet(func(key, strategy string, size int) { persisted <- key })
+
+	const pages = 100
+	for i := 0; i < pages; i++ {
+		key := fmt.Sprintf("/page-%d:en", i)
+		if err := m.Set(key, []byte("<p>page</p>"), "static", "/page"); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	// Wait for the sampled background writes to land
+	want := pages * 3 / 10
+	for i := 0; i < want; i++ {
+		select {
+		case <-persisted:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d sampled writes persisted", i, want)
+		}
+	}
+
+	var html int
+	for _, file := range cacheFiles(t, dir) {
+		if filepath.Ext(file) == ".html" {
+			html++
+		}
+	}
+	if html != want {
+		t.Errorf("pages on disk = %d, want %d", html, want)
+	}
+	if got := m.Stats().Entries; got != pages {
+		t.Errorf("pages in memory = %d, want %d", got, pages)
+	}
+}
+
+func TestOnSetCallback(t *testing.T) {
+	type call struct {
+		key, strategy string
+		size          int
+	}
+
+	m := newTestManager(t)
+	calls := make(chan call, 2)
+	m.SetOnSet(func(key, strategy string, size int) {
+		calls <- call{key, strategy, size}
+	})
+
+	if err := m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	if err := m.Set("/news:en", []byte("<p>latest news</p>"), "incremental", "/news"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	want := []call{
+		{"/about:en", "static", len("<p>about</p>")},
+		{"/news:en", "incremental", len("<p>latest news</p>")},
+	}
+	for _, expected := range want {
+		select {
+		case got := <-calls:
+			if got != expected {
+				t.Errorf("callback = %+v, want %+v", got, expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("callback for %s not invoked", expected.key)
+		}
+	}
+
+	// A panicking callback doesn't fail the write
+	m.SetOnSet(func(string, string, int) { panic("purge failed") })
+	if err := m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Errorf("SetSync with a panicking callback: %v", err)
+	}
+}
+
+func TestMinFreshAge(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMinFreshAge(time.Minute)
+	seed(t, m, "static", "fresh:en", "old:en", "other:en")
+	age(t, m, "old:en", 2*time.Minute)
+	age(t, m, "other:en", 2*time.Minute)
+
+	if got := m.MarkStale("static", false); got != 2 {
+		t.Errorf("MarkStale = %d, want 2", got)
+	}
+	fresh, _ := m.Get("fresh:en")
+	if fresh.IsStale() {
+		t.Error("entry inside the grace period marked stale")
+	}
+	old, _ := m.Get("old:en")
+	if !old.IsStale() {
+		t.Error("entry past the grace period not marked stale")
+	}
+
+	// MarkAllStale honors the grace period too, and zero disables it
+	if got := m.MarkAllStale(false); got != 2 {
+		t.Errorf("MarkAllStale = %d, want 2", got)
+	}
+	m.SetMinFreshAge(0)
+	m.MarkAllStale(false)
+	if !fresh.IsStale() {
+		t.Error("entry not marked stale with the grace period disabled")
+	}
+}
+
+func TestContentVersionInvalidatesEntries(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetContentVersion("templates-v1")
+	seed(t, m, "static", "about:en")
+	seed(t, m, "immutable", "logo:en")
+
+	// A restart with edited templates loads persisted entries as stale
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	restarted.SetContentVersion("templates-v2")
+	about, ok := restarted.Get("about:en")
+	if !ok {
+		t.Fatal("entry not loaded from disk")
+	}
+	if !about.IsStale() {
+		t.Error("entry rendered with old templates loaded as fresh")
+	}
+	logo, _ := restarted.Get("logo:en")
+	if logo.IsStale() {
+		t.Error("immutable entry marked stale")
+	}
+
+	// Changing the version at runtime marks entries stale; an unchanged one doesn't
+	seed(t, m, "static", "contact:en")
+	if got := m.SetContentVersion("templates-v1"); got != 0 {
+		t.Errorf("unchanged version marked %d entries stale", got)
+	}
+	if got := m.SetContentVersion("templates-v2"); got != 2 {
+		t.Errorf("changed version marked %d entries stale, want 2", got)
+	}
+}
+
+func TestUnavailableStorage(t *testing.T) {
+	// A regular file where a parent directory should be makes the cache dir uncreatable
+	blocker := filepath.Join(t.TempDir(), "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	dir := filepath.Join(blocker, "cache")
+
+	if _, err := NewManagerWithConfig(dir, ManagerConfig{StrictStorage: true}, discardLogger); err == nil {
+		t.Fatal("strict storage constructed a manager without a cache directory")
+	}
+
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if m.Persistent() {
+		t.Error("manager without storage reports itself persistent")
+	}
+	if err := m.SetSync("/about:en", []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	if got := content(t, m, "/about:en"); got != "<p>about</p>" {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestCompareAndSet(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static")
+
+	// Generation 0 only succeeds for a key that isn't cached yet
+	if ok, err := m.CompareAndSet("/about:en", 0, []byte("<p>first</p>"), "static", "/about"); !ok || err != nil {
+		t.Fatalf("CompareAndSet on a new key = %v, %v", ok, err)
+	}
+	entry, _ := m.Get("/about:en")
+	_, _, generation := entry.Snapshot()
+
+	// Two re-renders start from the same generation; the slower one loses
+	if ok, err := m.CompareAndSet("/about:en", generation, []byte("<p>fast</p>"), "static", "/about"); !ok || err != nil {
+		t.Fatalf("CompareAndSet at the current generation = %v, %v", ok, err)
+	}
+	if ok, err := m.CompareAndSet("/about:en", generation, []byte("<p>slow</p>"), "static", "/about"); ok || err != nil {
+		t.Errorf("CompareAndSet at a stale generation = %v, %v, want false", ok, err)
+	}
+	if got := content(t, m, "/about:en"); got != "<p>fast</p>" {
+		t.Errorf("content = %q, want the newer render", got)
+	}
+
+	if ok, _ := m.CompareAndSet("/about:en", 0, []byte("<p>new</p>"), "static", "/about"); ok {
+		t.Error("CompareAndSet with generation 0 overwrote a cached key")
+	}
+	if ok, _ := m.CompareAndSet("/missing:en", 3, []byte("<p>new</p>"), "static", "/missing"); ok {
+		t.Error("CompareAndSet against an expected generation created an entry")
+	}
+	if _, ok := m.Get("/missing:en"); ok {
+		t.Error("failed CompareAndSet left an entry behind")
+	}
+}
+
+func TestIncompressibleContent(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetCompressionWarnRatio(0)
+
+	random := make([]byte, 4096)
+	rand.Read(random)
+	if err := m.SetSync("random:en", random, "static", "/random"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	html := []byte(strings.Repeat("<p>compressible page</p>", 100))
+	if err := m.SetSync("page:en", html, "static", "/page"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	entry, _ := m.Get("random:en")
+	if !entry.Uncompressed || !bytes.Equal(entry.Content, random) {
+		t.Error("incompressible content not stored as-is")
+	}
+	if page, _ := m.Get("page:en"); page.Uncompressed {
+		t.Error("compressible content stored uncompressed")
+	}
+
+	// The flag survives a restart
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	reloaded, ok := restarted.Get("random:en")
+	if !ok {
+		t.Fatal("entry not loaded from disk")
+	}
+	if !reloaded.Uncompressed {
+		t.Error("reloaded entry lost its uncompressed flag")
+	}
+	if got := content(t, restarted, "random:en"); got != string(random) {
+		t.Error("reloaded content differs from the original")
+	}
+}
+
+// compressedEntry returns an entry holding html, as a staged rebuild would produce.
+func compressedEntry(t *testing.T, html, strategy string) *Entry {
+	t.Helper()
+	compressed, err := CompressBrotli([]byte(html))
+	if err != nil {
+		t.Fatalf("CompressBrotli: %v", err)
+	}
+	return NewEntry(compressed, strategy, "/")
+}
+
+func TestReplaceAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		pruneDisk bool
+	}{
+		{"keep disk", false},
+		{"prune disk", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			seed(t, m, "static", "a:en", "b:en")
+
+			next := map[string]*Entry{
+				"b:en": compressedEntry(t, "<p>new b</p>", "static"),
+				"c:en": compressedEntry(t, "<p>new c</p>", "static"),
+				"d:en": compressedEntry(t, "<p>new d</p>", "static"),
+			}
+
+			// Readers see the previous set or the new one, never a mix
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if n := len(m.List()); n != 2 && n != 3 {
+						t.Errorf("List returned %d entries mid-swap", n)
+						return
+					}
+				}
+			}()
+			if err := m.ReplaceAll(next, tt.pruneDisk); err != nil {
+				t.Fatalf("ReplaceAll: %v", err)
+			}
+			close(stop)
+			wg.Wait()
+
+			if inMemory(m, "a:en") {
+				t.Error("dropped key still in memory")
+			}
+			if got := content(t, m, "b:en"); got != "<p>new b</p>" {
+				t.Errorf("b:en = %q, want the replacement", got)
+			}
+			if got := content(t, m, "c:en"); got != "<p>new c</p>" {
+				t.Errorf("c:en = %q, want the replacement", got)
+			}
+
+			// Without pruning, the dropped key can still be loaded from disk
+			if _, ok := m.Get("a:en"); ok == tt.pruneDisk {
+				t.Errorf("dropped key loadable from disk = %v, want %v", ok, !tt.pruneDisk)
+			}
+		})
+	}
+}
+
+func TestEagerRevalidateOrderAndConcurrency(t *testing.T) {
+	m := newTestManager(t)
+	seed(t, m, "static", "b:en", "a:en")
+	seed(t, m, "incremental", "d:en", "c:en")
+	seed(t, m, "immutable", "e:en")
+
+	var stale []keyedEntry
+	for _, key := range []string{"e:en", "b:en", "d:en", "a:en", "c:en"} {
+		entry, _ := m.Get(key)
+		stale = append(stale, keyedEntry{key: key, entry: entry})
+	}
+
+	// One at a time: incremental first, then static, then the rest, each by path
+	var mu sync.Mutex
+	var order []string
+	m.SetRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+	}))
+	m.eagerRevalidate(stale, 1)
+	if want := []string{"/c:en", "/d:en", "/a:en", "/b:en", "/e:en"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	// Goroutines are only started as slots free up
+	const concurrency = 2
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	m.SetRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+	}))
+
+	before := runtime.NumGoroutine()
+	done := make(chan struct{})
+	go func() {
+		m.eagerRevalidate(stale, concurrency)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if extra := runtime.NumGoroutine() - before; extra > concurrency+1 {
+		t.Errorf("%d goroutines started for %d slots", extra, concurrency)
+	}
+	close(release)
+	<-done
+
+	if got := peak.Load(); got != concurrency {
+		t.Errorf("peak concurrent re-renders = %d, want %d", got, concurrency)
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Manifest returns the ETag of every in-memory entry keyed by cache key.
+// Nodes can compare manifests to find entries to fetch or invalidate.
+func (m *Manager) Manifest() map[string]string {
+	manifest := make(map[string]string)
+
+	m.entryMap().Range(func(key, value interface{}) bool {
+		_, etag, _ := value.(*Entry).Snapshot()
+		manifest[key.(string)] = etag
+		return true
+	})
+
+	return manifest
+}
+
+// DiskManifest returns the ETag of every entry persisted on disk, read from
+// the metadata sidecars. Entries written before sidecars existed are omitted.
+func (m *Manager) DiskManifest() (map[string]string, error) {
+	return m.storage.Manifest()
+}
+
+// Manifest reads every metadata sidecar and returns ETags keyed by cache key.
+func (s *Storage) Manifest() (map[string]string, error) {
+	metas, err := s.Metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make(map[string]string, len(metas))
+	for key, meta := range metas {
+		manifest[key] = meta.ETag
+	}
+
+	return manifest, nil
+}
+
+// Metadata reads every metadata sidecar (or the metadata index) and returns
+// the persisted metadata keyed by cache key.
+func (s *Storage) Metadata() (map[string]EntryMeta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	metas := make(map[string]EntryMeta)
+	if s.metaIndex != nil {
+		for key, meta := range s.metaIndex {
+			metas[key] = meta
+		}
+		return metas, nil
+	}
+
+	err := filepath.WalkDir(s.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), metaExt) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return newFileError("read cache metadata file", "", err)
+		}
+
+		var meta EntryMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Key == "" {
+			// Skip unreadable sidecars rather than failing the whole listing
+			return nil
+		}
+
+		metas[meta.Key] = meta
+		return nil
+	})
+	if err != nil {
+		return nil, newFileError("read cache directory", "", err)
+	}
+
+	return metas, nil
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	m := newTestManager(t)
+	want := make(map[string]string)
+	for _, key := range []string{"/about:en", "/about:tr", "/contact:en"} {
+		if err := m.SetSync(key, []byte("<p>"+key+"</p>"), "static", "/"); err != nil {
+			t.Fatalf("SetSync: %v", err)
+		}
+		entry, _ := m.Get(key)
+		_, etag, _ := entry.Snapshot()
+		want[key] = etag
+	}
+
+	if got := m.Manifest(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Manifest = %v, want %v", got, want)
+	}
+
+	disk, err := m.DiskManifest()
+	if err != nil {
+		t.Fatalf("DiskManifest: %v", err)
+	}
+	if !reflect.DeepEqual(disk, want) {
+		t.Errorf("DiskManifest = %v, want %v", disk, want)
+	}
+}
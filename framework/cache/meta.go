@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// metaExt is the file extension used for per-entry metadata sidecars.
+const metaExt = ".meta.json"
+
+// EntryMeta is the entry metadata persisted next to the cached content,
+// so entries loaded from disk keep their render time, strategy and ETag.
+type EntryMeta struct {
+	Key              string    `json:"key"`
+	RenderedAt       time.Time `json:"renderedAt"`
+	Strategy         string    `json:"strategy"`
+	ETag             string    `json:"etag"`
+	Generation       int64     `json:"generation"`
+	RequestPath      string    `json:"requestPath"`
+	Status           int       `json:"status,omitempty"`
+	Location         string    `json:"location,omitempty"`
+	CompressionRatio float64   `json:"compressionRatio,omitempty"`
+	ContentType      string    `json:"contentType,omitempty"`
+	ContentVersion   string    `json:"contentVersion,omitempty"`
+	Uncompressed     bool      `json:"uncompressed,omitempty"`
+	DataVersion      string    `json:"dataVersion,omitempty"`
+	Stale            bool      `json:"stale,omitempty"`
+}
+
+// Meta returns the entry's persistable metadata.
+func (e *Entry) Meta() EntryMeta {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return EntryMeta{
+		RenderedAt:       e.RenderedAt,
+		Strategy:         e.Strategy,
+		ETag:             e.ETag,
+		Generation:       e.Generation,
+		RequestPath:      e.RequestPath,
+		Status:           e.Status,
+		Location:         e.Location,
+		CompressionRatio: e.CompressionRatio,
+		ContentType:      e.ContentType,
+		Uncompressed:     e.Uncompressed,
+		DataVersion:      e.DataVersion,
+		Stale:            e.IsStale(),
+	}
+}
+
+// WriteMeta stores the metadata sidecar for the given key.
+func (s *Storage) WriteMeta(cacheKey string, meta EntryMeta) error {
+	meta.Key = cacheKey
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return newError("encode cache metadata", cacheKey, ErrStorage, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metaIndex != nil {
+		s.metaIndex[cacheKey] = meta
+		return s.appendMetaRecord(metaRecord{EntryMeta: meta})
+	}
+
+	metaPath := s.pathFor(cacheKey, metaExt)
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0755); err != nil {
+		return newError("create cache directory", cacheKey, ErrStorage, err)
+	}
+
+	done := s.trackWrite(cacheKey)
+	defer done(metaPath)
+
+	if err := s.writeFile(metaPath, data, 0644); err != nil {
+		return newError("write cache metadata file", cacheKey, ErrStorage, err)
+	}
+
+	return nil
+}
+
+// ReadMeta reads the metadata sidecar for the given key.
+// Entries written before sidecars existed report ErrNotFound.
+func (s *Storage) ReadMeta(cacheKey string) (EntryMeta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var meta EntryMeta
+
+	if s.metaIndex != nil {
+		meta, ok := s.metaIndex[cacheKey]
+		if !ok {
+			return meta, newError("read cache metadata index", cacheKey, ErrNotFound, os.ErrNotExist)
+		}
+		return meta, nil
+	}
+
+	data, err := os.ReadFile(s.pathFor(cacheKey, metaExt))
+	if err != nil {
+		return meta, newFileError("read cache metadata file", cacheKey, err)
+	}
+
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, newError("decode cache metadata", cacheKey, ErrCorrupt, err)
+	}
+
+	return meta, nil
+}
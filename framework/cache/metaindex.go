@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// metaIndexFile is the consolidated metadata log in the cache root, used
+	// instead of per-entry sidecars when the metadata index is enabled.
+	metaIndexFile = "meta-index.jsonl"
+
+	// metaIndexSlack is how many superseded lines the log may gather beyond
+	// the live keys before it is compacted.
+	metaIndexSlack = 1024
+)
+
+// metaRecord is a single line of the metadata index.
+// Later lines for a key supersede earlier ones; Deleted removes the key.
+type metaRecord struct {
+	EntryMeta
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// SetMetadataIndex switches between per-entry metadata sidecars and a single
+// append-only index file, which keeps the file count down on inode-limited
+// filesystems. Enabling it folds existing sidecars into the index and removes them.
+func (s *Storage) SetMetadataIndex(enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !enabled {
+		s.metaIndex = nil
+		return nil
+	}
+
+	index, err := s.readMetaIndex()
+	if err != nil {
+		return err
+	}
+
+	// Fold sidecars written before the index was enabled
+	var sidecars []string
+	err = filepath.WalkDir(s.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), metaExt) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var meta EntryMeta
+		if err := json.Unmarshal(data, &meta); err == nil && meta.Key != "" {
+			if _, exists := index[meta.Key]; !exists {
+				index[meta.Key] = meta
+			}
+		}
+		sidecars = append(sidecars, path)
+		return nil
+	})
+	if err != nil {
+		return newFileError("read cache directory", "", err)
+	}
+
+	s.metaIndex = index
+	s.metaAppends = 0
+	if err := s.compactMetaIndex(); err != nil {
+		return err
+	}
+
+	for _, path := range sidecars {
+		_ = os.Remove(path)
+	}
+
+	return nil
+}
+
+// readMetaIndex replays the index log. Unreadable lines, such as a write cut
+// short by a crash, are skipped. Callers must hold s.mu.
+func (s *Storage) readMetaIndex() (map[string]EntryMeta, error) {
+	index := make(map[string]EntryMeta)
+
+	data, err := os.ReadFile(filepath.Join(s.baseDir, metaIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, newFileError("read cache metadata index", "", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record metaRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Key == "" {
+			continue
+		}
+		if record.Deleted {
+			delete(index, record.Key)
+		} else {
+			index[record.Key] = record.EntryMeta
+		}
+	}
+
+	return index, nil
+}
+
+// compactMetaIndex rewrites the index log with one line per live key.
+// The new log is written beside the old one and renamed over it, so readers
+// never see a partial file. Callers must hold s.mu.
+func (s *Storage) compactMetaIndex() error {
+	indexPath := filepath.Join(s.baseDir, metaIndexFile)
+	tmpPath := indexPath + ".tmp"
+
+	for {
+		version := s.metaVersion
+
+		var buf bytes.Buffer
+		for _, meta := range s.metaIndex {
+			line, err := json.Marshal(metaRecord{EntryMeta: meta})
+			if err != nil {
+				return newError("encode cache metadata", meta.Key, ErrStorage, err)
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+
+		if err := s.writeFile(tmpPath, buf.Bytes(), 0644); err != nil {
+			return newError("write cache metadata index", "", ErrStorage, err)
+		}
+
+		// The lock is released while a write is retried; records appended in
+		// the meantime would be lost by renaming an outdated log over the live one
+		if s.metaVersion == version {
+			break
+		}
+	}
+
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return newError("replace cache metadata index", "", ErrStorage, err)
+	}
+
+	return nil
+}
+
+// appendMetaRecord appends a record to the index log, compacting it once
+// superseded lines outnumber the live keys. Callers must hold s.mu.
+func (s *Storage) appendMetaRecord(record metaRecord) error {
+	s.metaVersion++
+	if s.metaAppends > len(s.metaIndex)+metaIndexSlack {
+		s.metaAppends = 0
+		return s.compactMetaIndex()
+	}
+	s.metaAppends++
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return newError("encode cache metadata", record.Key, ErrStorage, err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.baseDir, metaIndexFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return newError("open cache metadata index", record.Key, ErrStorage, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return newError("append cache metadata index", record.Key, ErrStorage, err)
+	}
+
+	return nil
+}
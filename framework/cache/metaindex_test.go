@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sidecars returns the metadata sidecar files under dir.
+func sidecars(t *testing.T, dir string) []string {
+	t.Helper()
+	var found []string
+	for _, file := range cacheFiles(t, dir) {
+		if strings.HasSuffix(file, metaExt) {
+			found = append(found, file)
+		}
+	}
+	return found
+}
+
+func TestMetadataIndex(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	seed(t, m, "incremental", "/legacy:en")
+	if len(sidecars(t, dir)) != 1 {
+		t.Fatal("sidecar not written before the index was enabled")
+	}
+
+	// Enabling the index folds existing sidecars into it
+	indexed, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := indexed.SetMetadataIndex(true); err != nil {
+		t.Fatalf("SetMetadataIndex: %v", err)
+	}
+	if found := sidecars(t, dir); len(found) != 0 {
+		t.Errorf("sidecars left after enabling the index: %v", found)
+	}
+	seed(t, indexed, "immutable", "/logo:en", "/old:en")
+	if err := indexed.Delete("/old:en"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if found := sidecars(t, dir); len(found) != 0 {
+		t.Errorf("sidecars written with the index enabled: %v", found)
+	}
+
+	// A crash can leave a partial line at the end of the log
+	file, err := os.OpenFile(filepath.Join(dir, metaIndexFile), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open index: %v", err)
+	}
+	file.WriteString(`{"key":"/torn:en","strat`)
+	file.Close()
+
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if err := restarted.SetMetadataIndex(true); err != nil {
+		t.Fatalf("SetMetadataIndex after restart: %v", err)
+	}
+	for key, strategy := range map[string]string{"/legacy:en": "incremental", "/logo:en": "immutable"} {
+		entry, ok := restarted.Get(key)
+		if !ok {
+			t.Errorf("%s not reloaded", key)
+			continue
+		}
+		if entry.Strategy != strategy {
+			t.Errorf("%s: strategy = %q, want %q from the index", key, entry.Strategy, strategy)
+		}
+	}
+	if _, ok := restarted.Get("/old:en"); ok {
+		t.Error("deleted entry reloaded")
+	}
+}
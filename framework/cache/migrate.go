@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheFileExts lists the extensions of files managed by Storage.
+var cacheFileExts = []string{".br", ".html", metaExt, tombstoneExt}
+
+// Migrate moves cache files written under another directory layout (flat or
+// per-language) into the currently configured one, so enabling or disabling
+// language directories does not leave a cold cache behind. Each file is moved
+// with a single rename; running it again is a no-op. When a file exists in both
+// layouts, the copy in the current layout wins. Returns the number of files moved.
+func (s *Storage) Migrate(logger *slog.Logger) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dirEntries, err := os.ReadDir(s.baseDir)
+	if err != nil {
+		return 0, newFileError("read cache directory", "", err)
+	}
+
+	moved := 0
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			// Flat files only move when language directories are enabled
+			if s.languageDirs {
+				n, err := s.migrateFile(filepath.Join(s.baseDir, dirEntry.Name()), logger)
+				if err != nil {
+					return moved, err
+				}
+				moved += n
+			}
+			continue
+		}
+
+		// Language directory files only move back when language directories are disabled
+		if s.languageDirs {
+			continue
+		}
+
+		lang := dirEntry.Name()
+		langDir := filepath.Join(s.baseDir, lang)
+		files, err := os.ReadDir(langDir)
+		if err != nil {
+			return moved, newFileError("read cache directory", "", err)
+		}
+
+		for _, file := range files {
+			if file.IsDir() || fileLanguage(file.Name()) != lang {
+				continue
+			}
+
+			n, err := s.migrateFile(filepath.Join(langDir, file.Name()), logger)
+			if err != nil {
+				return moved, err
+			}
+			moved += n
+		}
+
+		// Remove the language directory once it is empty
+		_ = os.Remove(langDir)
+	}
+
+	if moved > 0 {
+		logger.Info("migrated cache files to current layout",
+			slog.Int("count", moved),
+			slog.Bool("language_dirs", s.languageDirs),
+		)
+	}
+
+	return moved, nil
+}
+
+// migrateFile moves a single cache file to its location in the current layout.
+// Callers must hold s.mu.
+func (s *Storage) migrateFile(path string, logger *slog.Logger) (int, error) {
+	name := filepath.Base(path)
+	lang := fileLanguage(name)
+	if lang == "" {
+		return 0, nil
+	}
+
+	target := filepath.Join(s.baseDir, name)
+	if s.languageDirs {
+		target = filepath.Join(s.baseDir, lang, name)
+	}
+	if target == path {
+		return 0, nil
+	}
+
+	// The current layout is authoritative; drop the outdated copy
+	if _, err := os.Stat(target); err == nil {
+		logger.Debug("removing cache file superseded by current layout",
+			slog.String("file", path),
+		)
+		_ = os.Remove(path)
+		return 0, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return 0, newFileError("stat cache file", "", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, newError("create cache directory", "", ErrStorage, err)
+	}
+
+	if err := os.Rename(path, target); err != nil {
+		return 0, newError("move cache file", "", ErrStorage, err)
+	}
+
+	logger.Debug("migrated cache file",
+		slog.String("from", path),
+		slog.String("to", target),
+	)
+
+	return 1, nil
+}
+
+// fileLanguage extracts the language from a cache file name such as "about_en.br".
+// Returns "" for files not managed by Storage.
+func fileLanguage(name string) string {
+	for _, ext := range cacheFileExts {
+		if base, found := strings.CutSuffix(name, ext); found {
+			if i := strings.LastIndex(base, "_"); i >= 0 {
+				return base[i+1:]
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// MigrateStorage moves cache files written under a previous directory layout
+// into the current one. Call it after SetLanguageDirectories, before serving.
+func (m *Manager) MigrateStorage() (int, error) {
+	if m.diskless {
+		return 0, nil
+	}
+	return m.storage.Migrate(m.logger)
+}
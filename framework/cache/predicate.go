@@ -0,0 +1,78 @@
+package cache
+
+// RevalidateFunc decides whether an entry needs re-rendering beyond the
+// time and stale-flag rules. It runs on every ShouldRevalidate call, so it must be cheap.
+type RevalidateFunc func(entry *Entry) bool
+
+// DataVersionFunc returns the current version of the data a page is rendered from.
+type DataVersionFunc func(requestPath string) string
+
+// revalidator holds the hooks registered for a strategy.
+type revalidator struct {
+	check   RevalidateFunc
+	version DataVersionFunc // Stamps DataVersion on render; nil leaves it empty
+}
+
+// RegisterRevalidator attaches fn to all entries of the given strategy, so
+// ShouldRevalidate reports true whenever fn does. A nil fn removes the hook.
+func (m *Manager) RegisterRevalidator(strategy string, fn RevalidateFunc) {
+	m.registerRevalidator(strategy, revalidator{check: fn})
+}
+
+// RegisterDataVersion makes the given strategy data-driven: each render stores
+// current(requestPath) as the entry's DataVersion, and the entry revalidates
+// once the current version differs from the stored one.
+func (m *Manager) RegisterDataVersion(strategy string, current DataVersionFunc) {
+	if current == nil {
+		m.registerRevalidator(strategy, revalidator{})
+		return
+	}
+
+	m.registerRevalidator(strategy, revalidator{
+		check: func(entry *Entry) bool {
+			entry.mu.RLock()
+			stored, requestPath := entry.DataVersion, entry.RequestPath
+			entry.mu.RUnlock()
+			return current(requestPath) != stored
+		},
+		version: current,
+	})
+}
+
+// registerRevalidator stores the hooks and applies them to existing entries.
+func (m *Manager) registerRevalidator(strategy string, hooks revalidator) {
+	m.mu.Lock()
+	if m.revalidators == nil {
+		m.revalidators = make(map[string]revalidator)
+	}
+	if hooks.check == nil {
+		delete(m.revalidators, strategy)
+	} else {
+		m.revalidators[strategy] = hooks
+	}
+	m.mu.Unlock()
+
+	m.entryMap().Range(func(key, value interface{}) bool {
+		entry := value.(*Entry)
+		if entry.Strategy == strategy {
+			entry.setRevalidateFunc(hooks.check)
+		}
+		return true
+	})
+}
+
+// revalidatorFor returns the hooks registered for a strategy.
+func (m *Manager) revalidatorFor(strategy string) revalidator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.revalidators[strategy]
+}
+
+// setRevalidateFunc attaches or, with nil, removes the entry's revalidation predicate.
+func (e *Entry) setRevalidateFunc(fn RevalidateFunc) {
+	if fn == nil {
+		e.revalidate.Store(nil)
+		return
+	}
+	e.revalidate.Store(&fn)
+}
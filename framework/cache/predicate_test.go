@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRegisterRevalidator(t *testing.T) {
+	m := newTestManager(t)
+	seed(t, m, "static", "old:en")
+
+	var mu sync.Mutex
+	outdated := map[string]bool{"/old:en": true, "/new:en": true}
+	m.RegisterRevalidator("static", func(entry *Entry) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return outdated[entry.RequestPath]
+	})
+	seed(t, m, "static", "new:en", "fresh:en")
+	seed(t, m, "incremental", "other:en")
+
+	// The predicate applies to entries cached before and after registration
+	for key, want := range map[string]bool{"old:en": true, "new:en": true, "fresh:en": false, "other:en": false} {
+		entry, _ := m.Get(key)
+		if got := entry.ShouldRevalidate(); got != want {
+			t.Errorf("%s ShouldRevalidate = %v, want %v", key, got, want)
+		}
+	}
+
+	m.RegisterRevalidator("static", nil)
+	if entry, _ := m.Get("old:en"); entry.ShouldRevalidate() {
+		t.Error("removed predicate still forces revalidation")
+	}
+}
+
+func TestRegisterDataVersion(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	var mu sync.Mutex
+	version := "v1"
+	current := func(requestPath string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return version
+	}
+	m.RegisterDataVersion("incremental", current)
+	seed(t, m, "incremental", "post:en")
+
+	entry, _ := m.Get("post:en")
+	if entry.DataVersion != "v1" || entry.ShouldRevalidate() {
+		t.Fatalf("DataVersion = %q, ShouldRevalidate = %v, want v1 and false", entry.DataVersion, entry.ShouldRevalidate())
+	}
+
+	mu.Lock()
+	version = "v2"
+	mu.Unlock()
+	if !entry.ShouldRevalidate() {
+		t.Error("entry not revalidated after the data changed")
+	}
+
+	// The stored version survives a restart
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	restarted.RegisterDataVersion("incremental", current)
+	loaded, found := restarted.Get("post:en")
+	if !found {
+		t.Fatal("entry not loaded after restart")
+	}
+	if loaded.DataVersion != "v1" || !loaded.ShouldRevalidate() {
+		t.Errorf("reloaded DataVersion = %q, want v1 pending revalidation", loaded.DataVersion)
+	}
+
+	// Re-rendering stamps the new version
+	seed(t, m, "incremental", "post:en")
+	entry, _ = m.Get("post:en")
+	if entry.DataVersion != "v2" || entry.ShouldRevalidate() {
+		t.Errorf("re-rendered DataVersion = %q, ShouldRevalidate = %v, want v2 and false", entry.DataVersion, entry.ShouldRevalidate())
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// OlderThan returns in-memory entries rendered before the cutoff, oldest first.
+func (m *Manager) OlderThan(cutoff time.Time) []EntryInfo {
+	var infos []EntryInfo
+
+	for _, info := range m.List() {
+		if info.RenderedAt.Before(cutoff) {
+			infos = append(infos, info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].RenderedAt.Before(infos[j].RenderedAt)
+	})
+
+	return infos
+}
+
+// PurgeOlderThan deletes entries rendered before the cutoff from memory and disk,
+// e.g. everything cached before the last deploy. Entries only on disk are judged
+// by their metadata sidecar; entries without one are kept. Immutable and pinned
+// entries are skipped unless force is set. Returns the number of entries deleted.
+func (m *Manager) PurgeOlderThan(cutoff time.Time, force bool) (int, error) {
+	candidates := make(map[string]bool)
+
+	m.entryMap().Range(func(key, value interface{}) bool {
+		entry := value.(*Entry)
+		entry.mu.RLock()
+		renderedAt := entry.RenderedAt
+		entry.mu.RUnlock()
+
+		if !force && (entry.Strategy == "immutable" || entry.IsPinned()) {
+			candidates[key.(string)] = false
+			return true
+		}
+		candidates[key.(string)] = renderedAt.Before(cutoff)
+		return true
+	})
+
+	if !m.diskless {
+		metas, err := m.storage.Metadata()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read cache metadata: %w", err)
+		}
+
+		for key, meta := range metas {
+			// The in-memory entry is authoritative for keys that are loaded
+			if _, loaded := candidates[key]; loaded {
+				continue
+			}
+			if !force && meta.Strategy == "immutable" {
+				continue
+			}
+			candidates[key] = meta.RenderedAt.Before(cutoff)
+		}
+	}
+
+	count := 0
+	for key, purge := range candidates {
+		if !purge {
+			continue
+		}
+		if err := m.Delete(key); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	m.logger.Info("purged old cache entries",
+		slog.Time("cutoff", cutoff),
+		slog.Int("count", count),
+		slog.Bool("force", force),
+	)
+
+	return count, nil
+}
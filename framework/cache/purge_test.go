@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPurgeOlderThan(t *testing.T) {
+	setup := func(t *testing.T) *Manager {
+		t.Helper()
+		m := newTestManager(t)
+		seed(t, m, "static", "old:en", "older:en", "new:en", "pinned:en", "disk:en")
+		seed(t, m, "immutable", "logo:en")
+		for key, by := range map[string]time.Duration{
+			"old:en": 2 * time.Hour, "older:en": 3 * time.Hour, "pinned:en": 2 * time.Hour,
+			"logo:en": 2 * time.Hour, "disk:en": 2 * time.Hour,
+		} {
+			age(t, m, key, by)
+		}
+		m.Pin("pinned:en")
+
+		// An old entry only on disk is judged by its sidecar
+		disk, _ := m.Get("disk:en")
+		if err := m.storage.WriteMeta("disk:en", disk.Meta()); err != nil {
+			t.Fatalf("WriteMeta: %v", err)
+		}
+		m.entryMap().Delete("disk:en")
+		return m
+	}
+	cutoff := time.Now().Add(-time.Hour)
+
+	m := setup(t)
+	var listed []string
+	for _, info := range m.OlderThan(cutoff) {
+		listed = append(listed, info.Key)
+	}
+	if want := []string{"older:en", "old:en", "pinned:en", "logo:en"}; !reflect.DeepEqual(listed, want) {
+		t.Errorf("OlderThan = %v, want %v", listed, want)
+	}
+
+	tests := []struct {
+		name  string
+		force bool
+		kept  []string
+	}{
+		{"default", false, []string{"logo:en", "new:en", "pinned:en"}},
+		{"force", true, []string{"new:en"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setup(t)
+			count, err := m.PurgeOlderThan(cutoff, tt.force)
+			if err != nil {
+				t.Fatalf("PurgeOlderThan: %v", err)
+			}
+			if want := 6 - len(tt.kept); count != want {
+				t.Errorf("count = %d, want %d", count, want)
+			}
+
+			var kept []string
+			for _, key := range []string{"old:en", "older:en", "new:en", "pinned:en", "disk:en", "logo:en"} {
+				if _, ok := m.Get(key); ok {
+					kept = append(kept, key)
+				}
+			}
+			sort.Strings(kept)
+			if !reflect.DeepEqual(kept, tt.kept) {
+				t.Errorf("kept = %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
@@ -12,6 +14,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	fwctx "statigo/framework/context"
 )
 
 // RouteConfig represents a route configuration for bootstrap caching.
@@ -28,45 +32,117 @@ type RebuildConfig struct {
 	Languages    []string
 	Router       http.Handler
 	Logger       *slog.Logger
-	ForceRebuild bool // If true, rebuild even if cache exists
+	ForceRebuild bool                 // If true, rebuild even if cache exists
+	RetryCount   int                  // Attempts per page in RetryFailed (default: 3)
+	RetryDelay   time.Duration        // Initial backoff between retries, doubled per attempt (default: 500ms)
+	Workers      int                  // Routes processed in parallel by Bootstrap and rebuilds (default: 10)
+	OnProgress   func(WarmupProgress) // Called by Bootstrap after each route, in order (optional)
+	// MinCoverage is the fraction of cacheable pages (0-1) Bootstrap must have
+	// cached, fresh or newly warmed, to succeed; below it Bootstrap returns
+	// ErrLowCoverage so startup can fail (0 = disabled).
+	MinCoverage float64
+	// QueueFailures hands pages Bootstrap failed to warm to the StaleWarmer,
+	// which retries them ahead of stale entries (see QueuePriorityWarm).
+	QueueFailures bool
+}
+
+// ErrLowCoverage is returned by Bootstrap when fewer pages were warmed than
+// RebuildConfig.MinCoverage requires.
+var ErrLowCoverage = errors.New("cache warm-up coverage below minimum")
+
+// WarmupProgress reports how many pages Bootstrap has processed out of the
+// cacheable route/language pairs. Skipped and failed pages count as processed.
+type WarmupProgress struct {
+	Done  int
+	Total int
+}
+
+// Percent returns the progress as a percentage; no cacheable pages count as complete.
+func (p WarmupProgress) Percent() float64 {
+	if p.Total == 0 {
+		return 100
+	}
+	return float64(p.Done) / float64(p.Total) * 100
+}
+
+// WarmupProgress returns the progress of the running (or last) Bootstrap.
+func (m *Manager) WarmupProgress() WarmupProgress {
+	m.warmupMu.Lock()
+	defer m.warmupMu.Unlock()
+	return m.warmup
+}
+
+// advanceWarmup records processed pages and reports the updated progress.
+// The callback runs under the lock so reported values never go backwards.
+func (m *Manager) advanceWarmup(pages int, onProgress func(WarmupProgress)) {
+	m.warmupMu.Lock()
+	defer m.warmupMu.Unlock()
+	m.warmup.Done += pages
+	if onProgress != nil {
+		onProgress(m.warmup)
+	}
+}
+
+// workerCount returns the configured route worker count, defaulting to 10.
+func (c RebuildConfig) workerCount() int {
+	if c.Workers < 1 {
+		return 10
+	}
+	return c.Workers
+}
+
+// WarmFailure records a route and language that failed to render or store.
+type WarmFailure struct {
+	Route RouteConfig
+	Lang  string
+	Err   error
 }
 
 // RebuildAll rebuilds all caches from routes configuration.
 func (m *Manager) RebuildAll(ctx context.Context, config RebuildConfig) (int, error) {
 	config.ForceRebuild = true
-	return m.rebuildCaches(ctx, config, "")
+	count, _, err := m.rebuildCaches(ctx, config, "")
+	return count, err
 }
 
 // RebuildByStrategy rebuilds caches filtered by strategy.
 func (m *Manager) RebuildByStrategy(ctx context.Context, config RebuildConfig, strategy string) (int, error) {
 	config.ForceRebuild = true
-	return m.rebuildCaches(ctx, config, strategy)
+	count, _, err := m.rebuildCaches(ctx, config, strategy)
+	return count, err
 }
 
 // Bootstrap pre-caches all cacheable pages on startup.
-func (m *Manager) Bootstrap(ctx context.Context, config RebuildConfig) error {
+// Pages already on disk and still within their strategy's freshness window are skipped.
+// Returns the pages that failed to warm so they can be passed to RetryFailed.
+func (m *Manager) Bootstrap(ctx context.Context, config RebuildConfig) ([]WarmFailure, error) {
 	config.Logger.Info("Starting bootstrap cache warming...")
 
 	// Load routes configuration
-	data, err := fs.ReadFile(config.ConfigFS, config.RoutesFile)
+	routes, err := loadRoutes(config)
 	if err != nil {
-		return fmt.Errorf("failed to read routes file: %w", err)
-	}
-
-	var routesConfig struct {
-		Routes []RouteConfig `json:"routes"`
-	}
-
-	if err := json.Unmarshal(data, &routesConfig); err != nil {
-		return fmt.Errorf("failed to parse routes JSON: %w", err)
+		return nil, err
 	}
 
 	var totalCached atomic.Int32
+	var failures []WarmFailure
+	var failuresMu sync.Mutex
 	startTime := time.Now()
 
+	// Count cacheable pages up front so progress can be reported as a fraction
+	total := 0
+	for _, route := range routes {
+		if route.Strategy != "dynamic" && !strings.Contains(route.Canonical, "{") {
+			total += len(config.Languages)
+		}
+	}
+	m.warmupMu.Lock()
+	m.warmup = WarmupProgress{Total: total}
+	m.warmupMu.Unlock()
+
 	// Use worker pool for parallel processing
-	maxWorkers := 10
-	routeChan := make(chan RouteConfig, len(routesConfig.Routes))
+	maxWorkers := config.workerCount()
+	routeChan := make(chan RouteConfig, len(routes))
 	var wg sync.WaitGroup
 
 	// Start workers
@@ -87,21 +163,21 @@ func (m *Manager) Bootstrap(ctx context.Context, config RebuildConfig) error {
 				hasParams := strings.Contains(route.Canonical, "{")
 
 				var count int
-				var err error
+				var routeFailures []WarmFailure
 
 				if !hasParams {
 					config.Logger.Debug("Processing static route",
 						slog.String("canonical", route.Canonical),
 						slog.String("strategy", route.Strategy),
 					)
-					count, err = m.cacheStaticRoute(ctx, route, config)
-					if err != nil {
-						config.Logger.Error("Failed to cache static route",
-							slog.String("canonical", route.Canonical),
-							slog.String("error", err.Error()),
-						)
-						continue
-					}
+					count, routeFailures = m.cacheStaticRoute(ctx, route, config)
+					m.advanceWarmup(len(config.Languages), config.OnProgress)
+				}
+
+				if len(routeFailures) > 0 {
+					failuresMu.Lock()
+					failures = append(failures, routeFailures...)
+					failuresMu.Unlock()
 				}
 
 				totalCached.Add(int32(count))
@@ -110,7 +186,7 @@ func (m *Manager) Bootstrap(ctx context.Context, config RebuildConfig) error {
 	}
 
 	// Send routes to workers
-	for _, route := range routesConfig.Routes {
+	for _, route := range routes {
 		routeChan <- route
 	}
 	close(routeChan)
@@ -121,21 +197,206 @@ func (m *Manager) Bootstrap(ctx context.Context, config RebuildConfig) error {
 	duration := time.Since(startTime)
 	config.Logger.Info("Bootstrap cache warming completed",
 		slog.Int("total_pages", int(totalCached.Load())),
+		slog.Int("failed_pages", len(failures)),
 		slog.Duration("duration", duration),
 	)
 
+	if config.QueueFailures && len(failures) > 0 {
+		m.QueuePriorityWarm(failures)
+	}
+
+	if config.MinCoverage > 0 && total > 0 {
+		coverage := float64(total-len(failures)) / float64(total)
+		if coverage < config.MinCoverage {
+			return failures, fmt.Errorf("%w: %.1f%% of %d pages warmed, %.1f%% required",
+				ErrLowCoverage, coverage*100, total, config.MinCoverage*100)
+		}
+	}
+
+	return failures, nil
+}
+
+// RetryFailed re-attempts pages that failed to warm, with exponential backoff.
+// Returns the pages that still failed after all attempts.
+func (m *Manager) RetryFailed(ctx context.Context, failures []WarmFailure, config RebuildConfig) ([]WarmFailure, error) {
+	retryCount := config.RetryCount
+	if retryCount <= 0 {
+		retryCount = 3
+	}
+	delay := config.RetryDelay
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+
+	pending := failures
+	for attempt := 1; attempt <= retryCount && len(pending) > 0; attempt++ {
+		select {
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		config.Logger.Info("Retrying failed cache warming",
+			slog.Int("attempt", attempt),
+			slog.Int("pages", len(pending)),
+		)
+
+		var remaining []WarmFailure
+		for _, failure := range pending {
+			if _, err := m.warmPage(ctx, failure.Route, failure.Lang, config); err != nil {
+				failure.Err = err
+				remaining = append(remaining, failure)
+			}
+		}
+
+		pending = remaining
+		delay *= 2
+	}
+
+	if len(pending) > 0 {
+		config.Logger.Warn("Some pages still failed after retries",
+			slog.Int("pages", len(pending)),
+		)
+	}
+
+	return pending, nil
+}
+
+// RebuildCanonical re-renders a single canonical route for every configured language,
+// leaving all other entries untouched. Returns the number of pages cached.
+func (m *Manager) RebuildCanonical(ctx context.Context, config RebuildConfig, canonical string) (int, error) {
+	routes, err := loadRoutes(config)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, route := range routes {
+		if route.Canonical != canonical {
+			continue
+		}
+
+		if route.Strategy == "dynamic" {
+			return 0, fmt.Errorf("route is not cacheable: %s", canonical)
+		}
+		if strings.Contains(route.Canonical, "{") {
+			return 0, fmt.Errorf("route has parameters and cannot be pre-rendered: %s", canonical)
+		}
+
+		config.ForceRebuild = true
+		count, failures := m.cacheStaticRoute(ctx, route, config)
+
+		config.Logger.Info("Canonical cache rebuild completed",
+			slog.String("canonical", canonical),
+			slog.Int("total_cached", count),
+			slog.Int("failed_pages", len(failures)),
+		)
+
+		if len(failures) > 0 {
+			return count, fmt.Errorf("failed to rebuild %s for %d languages: %w", canonical, len(failures), failures[0].Err)
+		}
+		return count, nil
+	}
+
+	return 0, fmt.Errorf("canonical not found in routes: %s", canonical)
+}
+
+// WarmOne renders a single page through the router and stores it synchronously,
+// returning once the entry is in memory and on disk. Useful for critical pages
+// at startup and in tests, where Bootstrap would be overkill.
+func (m *Manager) WarmOne(ctx context.Context, router http.Handler, canonical, lang, path, strategy string) error {
+	cacheKey := GetCacheKey(canonical, lang, nil)
+	if m.IsTombstoned(cacheKey) {
+		return fmt.Errorf("cannot warm tombstoned page: %s", cacheKey)
+	}
+
+	content, status, err := m.makeCacheRequest(ctx, router, path)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", path, err)
+	}
+
+	if err := m.setPage(cacheKey, content, strategy, warmRevision(path, status), true); err != nil {
+		return fmt.Errorf("failed to store %s: %w", cacheKey, err)
+	}
+
 	return nil
 }
 
-// rebuildCaches is the internal method that rebuilds caches.
-func (m *Manager) rebuildCaches(ctx context.Context, config RebuildConfig, strategyFilter string) (int, error) {
-	config.Logger.Info("Starting cache rebuild",
-		slog.String("strategy", strategyFilter),
+// WarmLanguage renders and stores every cacheable route in a single language,
+// e.g. after adding a new locale, leaving other languages untouched. Dynamic and
+// parameterized routes, and routes without a path for the language, are skipped.
+// Pages already cached and fresh are kept unless config.ForceRebuild is set.
+// Returns the number of pages cached.
+func (m *Manager) WarmLanguage(ctx context.Context, config RebuildConfig, lang string) (int, error) {
+	routes, err := loadRoutes(config)
+	if err != nil {
+		return 0, err
+	}
+
+	config.Languages = []string{lang}
+	startTime := time.Now()
+
+	var totalCached atomic.Int32
+	var failures []WarmFailure
+	var failuresMu sync.Mutex
+
+	maxWorkers := config.workerCount()
+	routeChan := make(chan RouteConfig, len(routes))
+	var wg sync.WaitGroup
+
+	for i := 0; i < maxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for route := range routeChan {
+				if route.Strategy == "dynamic" || strings.Contains(route.Canonical, "{") {
+					continue
+				}
+
+				if route.Paths[lang] == "" {
+					config.Logger.Debug("Skipping route without a path for language",
+						slog.String("canonical", route.Canonical),
+						slog.String("lang", lang),
+					)
+					continue
+				}
+
+				count, routeFailures := m.cacheStaticRoute(ctx, route, config)
+				totalCached.Add(int32(count))
+
+				if len(routeFailures) > 0 {
+					failuresMu.Lock()
+					failures = append(failures, routeFailures...)
+					failuresMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, route := range routes {
+		routeChan <- route
+	}
+	close(routeChan)
+
+	wg.Wait()
+
+	config.Logger.Info("Language cache warming completed",
+		slog.String("lang", lang),
+		slog.Int("total_cached", int(totalCached.Load())),
+		slog.Int("failed_pages", len(failures)),
+		slog.Duration("duration", time.Since(startTime)),
 	)
 
+	if len(failures) > 0 {
+		return int(totalCached.Load()), fmt.Errorf("failed to warm %d pages for %s: %w", len(failures), lang, failures[0].Err)
+	}
+	return int(totalCached.Load()), nil
+}
+
+// loadRoutes reads and parses the routes configuration file.
+func loadRoutes(config RebuildConfig) ([]RouteConfig, error) {
 	data, err := fs.ReadFile(config.ConfigFS, config.RoutesFile)
 	if err != nil {
-		return 0, fmt.Errorf("failed to read routes file: %w", err)
+		return nil, fmt.Errorf("failed to read routes file: %w", err)
 	}
 
 	var routesConfig struct {
@@ -143,14 +404,31 @@ func (m *Manager) rebuildCaches(ctx context.Context, config RebuildConfig, strat
 	}
 
 	if err := json.Unmarshal(data, &routesConfig); err != nil {
-		return 0, fmt.Errorf("failed to parse routes JSON: %w", err)
+		return nil, fmt.Errorf("failed to parse routes JSON: %w", err)
+	}
+
+	return routesConfig.Routes, nil
+}
+
+// rebuildCaches is the internal method that rebuilds caches.
+// Returns the pages that failed to render or store.
+func (m *Manager) rebuildCaches(ctx context.Context, config RebuildConfig, strategyFilter string) (int, []WarmFailure, error) {
+	config.Logger.Info("Starting cache rebuild",
+		slog.String("strategy", strategyFilter),
+	)
+
+	routes, err := loadRoutes(config)
+	if err != nil {
+		return 0, nil, err
 	}
 
 	var totalCached atomic.Int32
+	var failures []WarmFailure
+	var failuresMu sync.Mutex
 	startTime := time.Now()
 
-	maxWorkers := 10
-	routeChan := make(chan RouteConfig, len(routesConfig.Routes))
+	maxWorkers := config.workerCount()
+	routeChan := make(chan RouteConfig, len(routes))
 	var wg sync.WaitGroup
 
 	for i := 0; i < maxWorkers; i++ {
@@ -166,28 +444,24 @@ func (m *Manager) rebuildCaches(ctx context.Context, config RebuildConfig, strat
 					continue
 				}
 
-				hasParams := strings.Contains(route.Canonical, "{")
-
-				var count int
-				var err error
-
-				if !hasParams {
-					count, err = m.cacheStaticRoute(ctx, route, config)
-					if err != nil {
-						config.Logger.Error("Failed to cache static route",
-							slog.String("canonical", route.Canonical),
-							slog.String("error", err.Error()),
-						)
-						continue
-					}
+				// Parameterized routes are cached on demand, not rebuilt
+				if strings.Contains(route.Canonical, "{") {
+					continue
 				}
 
+				count, routeFailures := m.cacheStaticRoute(ctx, route, config)
 				totalCached.Add(int32(count))
+
+				if len(routeFailures) > 0 {
+					failuresMu.Lock()
+					failures = append(failures, routeFailures...)
+					failuresMu.Unlock()
+				}
 			}
 		}()
 	}
 
-	for _, route := range routesConfig.Routes {
+	for _, route := range routes {
 		routeChan <- route
 	}
 	close(routeChan)
@@ -198,15 +472,19 @@ func (m *Manager) rebuildCaches(ctx context.Context, config RebuildConfig, strat
 	config.Logger.Info("Cache rebuild completed",
 		slog.String("strategy", strategyFilter),
 		slog.Int("total_cached", int(totalCached.Load())),
+		slog.Int("failed_pages", len(failures)),
 		slog.Duration("duration", duration),
 	)
 
-	return int(totalCached.Load()), nil
+	return int(totalCached.Load()), failures, nil
 }
 
 // cacheStaticRoute caches a static route for all languages.
-func (m *Manager) cacheStaticRoute(ctx context.Context, route RouteConfig, config RebuildConfig) (int, error) {
+// Returns the number of pages cached and the pages that failed.
+func (m *Manager) cacheStaticRoute(ctx context.Context, route RouteConfig, config RebuildConfig) (int, []WarmFailure) {
 	var count atomic.Int32
+	var failures []WarmFailure
+	var failuresMu sync.Mutex
 	var wg sync.WaitGroup
 
 	for _, lang := range config.Languages {
@@ -214,68 +492,113 @@ func (m *Manager) cacheStaticRoute(ctx context.Context, route RouteConfig, confi
 		go func(lang string) {
 			defer wg.Done()
 
-			cacheKey := GetCacheKey(route.Canonical, lang, nil)
-
-			// Skip if already cached (unless force rebuild)
-			if !config.ForceRebuild {
-				if _, found := m.Get(cacheKey); found {
-					return
-				}
-			}
-
-			// Get the path for this language
-			path := route.Paths[lang]
-			if path == "" {
-				config.Logger.Warn("No path found for language",
-					slog.String("canonical", route.Canonical),
-					slog.String("lang", lang),
-				)
-				return
-			}
-
-			// Make HTTP request to render the page
-			content, err := m.makeCacheRequest(ctx, config.Router, path)
+			cached, err := m.warmPage(ctx, route, lang, config)
 			if err != nil {
-				config.Logger.Error("Failed to render page",
-					slog.String("canonical", route.Canonical),
-					slog.String("lang", lang),
-					slog.String("path", path),
-					slog.String("error", err.Error()),
-				)
+				failuresMu.Lock()
+				failures = append(failures, WarmFailure{Route: route, Lang: lang, Err: err})
+				failuresMu.Unlock()
 				return
 			}
 
-			// Store in cache (synchronous during rebuild)
-			if err := m.SetSync(cacheKey, content, route.Strategy, path); err != nil {
-				config.Logger.Error("Failed to store in cache",
-					slog.String("key", cacheKey),
-					slog.String("error", err.Error()),
-				)
-				return
+			if cached {
+				count.Add(1)
 			}
-
-			count.Add(1)
 		}(lang)
 	}
 
 	wg.Wait()
-	return int(count.Load()), nil
+	return int(count.Load()), failures
 }
 
-// makeCacheRequest makes an HTTP request to the router and returns the response body.
-func (m *Manager) makeCacheRequest(ctx context.Context, router http.Handler, path string) ([]byte, error) {
+// warmPage renders and stores a single route for one language.
+// Returns false without an error when the page was skipped.
+func (m *Manager) warmPage(ctx context.Context, route RouteConfig, lang string, config RebuildConfig) (bool, error) {
+	cacheKey := GetCacheKey(route.Canonical, lang, nil)
+
+	// Skip keys that were intentionally removed
+	if m.IsTombstoned(cacheKey) {
+		config.Logger.Debug("Skipping tombstoned page",
+			slog.String("key", cacheKey),
+		)
+		return false, nil
+	}
+
+	// Skip if already cached and still fresh (unless force rebuild).
+	// Entries whose strategy changed, or without persisted metadata for a
+	// non-static route, are re-rendered.
+	if !config.ForceRebuild {
+		if entry, found := m.Get(cacheKey); found {
+			if entry.Strategy == route.Strategy && !entry.ShouldRevalidate() {
+				return false, nil
+			}
+
+			config.Logger.Debug("Re-warming expired page",
+				slog.String("key", cacheKey),
+				slog.String("strategy", route.Strategy),
+			)
+		}
+	}
+
+	// Get the path for this language
+	path := route.Paths[lang]
+	if path == "" {
+		config.Logger.Warn("No path found for language",
+			slog.String("canonical", route.Canonical),
+			slog.String("lang", lang),
+		)
+		return false, nil
+	}
+
+	// Make HTTP request to render the page
+	content, status, err := m.makeCacheRequest(ctx, config.Router, path)
+	if err != nil {
+		config.Logger.Error("Failed to render page",
+			slog.String("canonical", route.Canonical),
+			slog.String("lang", lang),
+			slog.String("path", path),
+			slog.String("error", err.Error()),
+		)
+		return false, err
+	}
+
+	// Store in cache (synchronous during rebuild)
+	if err := m.setPage(cacheKey, content, route.Strategy, warmRevision(path, status), true); err != nil {
+		config.Logger.Error("Failed to store in cache",
+			slog.String("key", cacheKey),
+			slog.String("error", err.Error()),
+		)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// warmRevision describes a page rendered by the warm path with the given status.
+func warmRevision(path string, status int) revision {
+	if status == http.StatusOK {
+		status = 0
+	}
+	return revision{requestPath: path, status: status}
+}
+
+// makeCacheRequest makes an HTTP request to the router and returns the response body
+// and status. Statuses that are not cacheable are returned as errors.
+func (m *Manager) makeCacheRequest(ctx context.Context, router http.Handler, path string) ([]byte, int, error) {
 	req := httptest.NewRequest(http.MethodGet, path, nil)
-	req = req.WithContext(ctx)
 
-	// Add header to bypass rate limiting for bootstrap requests
-	req.Header.Set("X-Internal-Bootstrap", "true")
+	// Mark the request as internal so it bypasses rate limiting and cache lookups.
+	// A context value is used rather than a header, which any client could send.
+	req = req.WithContext(fwctx.MarkInternalRequest(ctx))
+
+	rec := AcquireRecorder(nil)
+	defer ReleaseRecorder(rec)
 
-	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
-	if rec.Code != http.StatusOK {
-		return nil, fmt.Errorf("request returned non-OK status: %d", rec.Code)
+	if !m.IsCacheableStatus(rec.StatusCode()) {
+		return nil, 0, fmt.Errorf("request returned non-cacheable status: %d", rec.StatusCode())
 	}
 
-	return rec.Body.Bytes(), nil
+	// Copy out of the pooled buffer, since the cache retains the content
+	return bytes.Clone(rec.Bytes()), rec.StatusCode(), nil
 }
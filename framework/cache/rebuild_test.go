@@ -0,0 +1,365 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyRouter fails the first failures requests with 503, then serves body.
+func flakyRouter(failures int32, body string) http.Handler {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(body))
+	})
+}
+
+func TestRetryFailedWarmsRecoveredPages(t *testing.T) {
+	m := newTestManager(t)
+	config := testRebuildConfig(t, flakyRouter(1, "<p>about</p>"), []string{"en"}, aboutRoute)
+	config.RetryDelay = time.Millisecond
+
+	failures, err := m.Bootstrap(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Route.Canonical != "/about" || failures[0].Lang != "en" {
+		t.Fatalf("failures = %+v, want /about in en", failures)
+	}
+
+	remaining, err := m.RetryFailed(context.Background(), failures, config)
+	if err != nil {
+		t.Fatalf("RetryFailed: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("remaining failures = %+v, want none", remaining)
+	}
+	if got := content(t, m, "/about:en"); got != "<p>about</p>" {
+		t.Errorf("content = %q, want the retried page", got)
+	}
+}
+
+// pathRouter serves each path as its own page and counts renders per path.
+func pathRouter(renders *sync.Map) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := renders.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		count.(*atomic.Int32).Add(1)
+		w.Write([]byte("<p>" + r.URL.Path + "</p>"))
+	})
+}
+
+// renderCount returns how often pathRouter rendered path.
+func renderCount(renders *sync.Map, path string) int32 {
+	if count, ok := renders.Load(path); ok {
+		return count.(*atomic.Int32).Load()
+	}
+	return 0
+}
+
+// route returns a single-language route whose path equals its canonical.
+func route(canonical, strategy string) RouteConfig {
+	return RouteConfig{Canonical: canonical, Paths: map[string]string{"en": canonical}, Strategy: strategy}
+}
+
+func TestBootstrapRewarmsOnlyExpiredEntries(t *testing.T) {
+	dir := t.TempDir()
+	previous, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	for _, canonical := range []string{"/fresh", "/expired"} {
+		if err := previous.SetSync(canonical+":en", []byte("<p>cached</p>"), "incremental", canonical); err != nil {
+			t.Fatalf("SetSync: %v", err)
+		}
+	}
+
+	// The expired page was rendered well past the incremental TTL
+	entry, _ := previous.Get("/expired:en")
+	meta := entry.Meta()
+	meta.RenderedAt = time.Now().Add(-3 * incrementalTTL)
+	if err := previous.storage.WriteMeta("/expired:en", meta); err != nil {
+		t.Fatalf("WriteMeta: %v", err)
+	}
+
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	var renders sync.Map
+	config := testRebuildConfig(t, pathRouter(&renders), []string{"en"},
+		route("/fresh", "incremental"), route("/expired", "incremental"))
+	if _, err := m.Bootstrap(context.Background(), config); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+
+	if got := renderCount(&renders, "/fresh"); got != 0 {
+		t.Errorf("fresh page rendered %d times, want 0", got)
+	}
+	if got := renderCount(&renders, "/expired"); got != 1 {
+		t.Errorf("expired page rendered %d times, want 1", got)
+	}
+	if got := content(t, m, "/expired:en"); got != "<p>/expired</p>" {
+		t.Errorf("expired page content = %q, want the new render", got)
+	}
+}
+
+func TestBootstrapRespectsWorkerCount(t *testing.T) {
+	m := newTestManager(t)
+	var active, peak atomic.Int32
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("<p>page</p>"))
+	})
+
+	var routes []RouteConfig
+	for i := 0; i < 12; i++ {
+		routes = append(routes, route(fmt.Sprintf("/page-%d", i), "static"))
+	}
+	config := testRebuildConfig(t, router, []string{"en"}, routes...)
+	config.Workers = 3
+
+	if _, err := m.Bootstrap(context.Background(), config); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if got := peak.Load(); got != 3 {
+		t.Errorf("peak concurrent renders = %d, want 3", got)
+	}
+}
+
+func TestRebuildCanonical(t *testing.T) {
+	m := newTestManager(t)
+	for _, key := range []string{"/about:en", "/about:tr", "/contact:en", "/contact:tr"} {
+		if err := m.SetSync(key, []byte("<p>old</p>"), "static", "/"); err != nil {
+			t.Fatalf("SetSync: %v", err)
+		}
+	}
+
+	var renders sync.Map
+	config := testRebuildConfig(t, pathRouter(&renders), []string{"en", "tr"},
+		RouteConfig{Canonical: "/about", Paths: map[string]string{"en": "/en/about", "tr": "/tr/hakkimizda"}, Strategy: "static"},
+		RouteConfig{Canonical: "/contact", Paths: map[string]string{"en": "/en/contact", "tr": "/tr/iletisim"}, Strategy: "static"},
+	)
+
+	count, err := m.RebuildCanonical(context.Background(), config, "/about")
+	if err != nil {
+		t.Fatalf("RebuildCanonical: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+
+	want := map[string]string{
+		"/about:en":   "<p>/en/about</p>",
+		"/about:tr":   "<p>/tr/hakkimizda</p>",
+		"/contact:en": "<p>old</p>",
+		"/contact:tr": "<p>old</p>",
+	}
+	for key, body := range want {
+		if got := content(t, m, key); got != body {
+			t.Errorf("%s = %q, want %q", key, got, body)
+		}
+	}
+
+	if _, err := m.RebuildCanonical(context.Background(), config, "/missing"); err == nil {
+		t.Error("rebuilding an unknown canonical succeeded")
+	}
+}
+
+func TestWarmOne(t *testing.T) {
+	m := newTestManager(t)
+	var renders sync.Map
+
+	if err := m.WarmOne(context.Background(), pathRouter(&renders), "/about", "tr", "/tr/hakkimizda", "static"); err != nil {
+		t.Fatalf("WarmOne: %v", err)
+	}
+
+	// The entry is in memory and on disk as soon as WarmOne returns
+	if got := content(t, m, "/about:tr"); got != "<p>/tr/hakkimizda</p>" {
+		t.Errorf("content = %q", got)
+	}
+	html, err := m.storage.ReadHTML("/about:tr")
+	if err != nil {
+		t.Fatalf("ReadHTML: %v", err)
+	}
+	if string(html) != "<p>/tr/hakkimizda</p>" {
+		t.Errorf("disk content = %q", html)
+	}
+
+	if err := m.Tombstone("/old", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+	if err := m.WarmOne(context.Background(), pathRouter(&renders), "/old", "en", "/old", "static"); err == nil {
+		t.Error("warming a tombstoned page succeeded")
+	}
+	if err := m.WarmOne(context.Background(), flakyRouter(1, "<p>down</p>"), "/down", "en", "/down", "static"); err == nil {
+		t.Error("warming a failing page succeeded")
+	}
+}
+
+func TestBootstrapProgress(t *testing.T) {
+	m := newTestManager(t)
+	var renders sync.Map
+	routes := []RouteConfig{
+		route("/a", "static"), route("/b", "static"), route("/c", "incremental"), route("/d", "static"),
+		route("/search", "dynamic"), route("/blog/{slug}", "static"),
+	}
+	config := testRebuildConfig(t, pathRouter(&renders), []string{"en", "tr"}, routes...)
+	config.Workers = 3
+
+	var reported []WarmupProgress
+	config.OnProgress = func(progress WarmupProgress) {
+		reported = append(reported, progress)
+	}
+
+	if _, err := m.Bootstrap(context.Background(), config); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+
+	// Dynamic and templated routes are not counted
+	if len(reported) != 4 {
+		t.Fatalf("progress reported %d times, want once per cacheable route", len(reported))
+	}
+	for i, progress := range reported {
+		if progress.Total != 8 {
+			t.Errorf("report %d: Total = %d, want 8", i, progress.Total)
+		}
+		if i > 0 && progress.Done <= reported[i-1].Done {
+			t.Errorf("report %d: Done went from %d to %d", i, reported[i-1].Done, progress.Done)
+		}
+	}
+	if got := m.WarmupProgress(); got.Done != 8 || got.Percent() != 100 {
+		t.Errorf("final progress = %+v (%.0f%%), want 8/8", got, got.Percent())
+	}
+}
+
+func TestWarmLanguage(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetSync("/about:en", []byte("<p>old</p>"), "static", "/en/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	var renders sync.Map
+	config := testRebuildConfig(t, pathRouter(&renders), []string{"en", "de"},
+		RouteConfig{Canonical: "/about", Paths: map[string]string{"en": "/en/about", "de": "/de/uber-uns"}, Strategy: "static"},
+		RouteConfig{Canonical: "/contact", Paths: map[string]string{"en": "/en/contact"}, Strategy: "static"},
+		RouteConfig{Canonical: "/search", Paths: map[string]string{"en": "/en/search", "de": "/de/suche"}, Strategy: "dynamic"},
+		RouteConfig{Canonical: "/blog/{slug}", Paths: map[string]string{"en": "/en/blog/{slug}", "de": "/de/blog/{slug}"}, Strategy: "static"},
+	)
+
+	count, err := m.WarmLanguage(context.Background(), config, "de")
+	if err != nil {
+		t.Fatalf("WarmLanguage: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+
+	if got := content(t, m, "/about:de"); got != "<p>/de/uber-uns</p>" {
+		t.Errorf("/about:de = %q", got)
+	}
+	// Other languages are left untouched; dynamic and templated routes are skipped
+	if got := content(t, m, "/about:en"); got != "<p>old</p>" {
+		t.Errorf("/about:en = %q, want it untouched", got)
+	}
+	for _, path := range []string{"/en/about", "/en/contact", "/de/suche", "/de/blog/{slug}"} {
+		if got := renderCount(&renders, path); got != 0 {
+			t.Errorf("%s rendered %d times, want 0", path, got)
+		}
+	}
+}
+
+func TestBootstrapMinCoverage(t *testing.T) {
+	tests := []struct {
+		name        string
+		minCoverage float64
+		wantErr     bool
+	}{
+		{"disabled", 0, false},
+		{"met", 0.5, false},
+		{"missed", 0.75, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t)
+			// One of two pages fails, so coverage is 50%.
+			router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/down" {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte("<p>up</p>"))
+			})
+			config := testRebuildConfig(t, router, []string{"en"}, route("/up", "static"), route("/down", "static"))
+			config.MinCoverage = tt.minCoverage
+
+			failures, err := m.Bootstrap(context.Background(), config)
+			if got := errors.Is(err, ErrLowCoverage); got != tt.wantErr {
+				t.Fatalf("Bootstrap error = %v, want ErrLowCoverage: %v", err, tt.wantErr)
+			}
+			if len(failures) != 1 || failures[0].Route.Canonical != "/down" {
+				t.Errorf("failures = %+v, want /down", failures)
+			}
+		})
+	}
+}
+
+func TestBootstrapQueuesFailuresForWarmer(t *testing.T) {
+	m := newTestManager(t)
+	var down atomic.Bool
+	down.Store(true)
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("<p>about</p>"))
+	})
+	m.SetRouter(router)
+	config := testRebuildConfig(t, router, []string{"en"}, aboutRoute)
+	config.QueueFailures = true
+
+	if _, err := m.Bootstrap(context.Background(), config); err != nil {
+		t.Fatalf("Bootstrap: %v", err)
+	}
+	if len(m.priority) != 1 {
+		t.Fatalf("queued %d pages, want 1", len(m.priority))
+	}
+
+	// Pages that fail again stay queued, backing off before the next attempt
+	m.warmPriority()
+	if len(m.priority) != 1 {
+		t.Fatalf("queued %d pages after a failed pass, want 1", len(m.priority))
+	}
+	down.Store(false)
+	m.warmPriority()
+	if len(m.priority) != 1 || m.priority[0].attempts != 1 {
+		t.Fatalf("queue = %+v, want the page waiting out its backoff", m.priority)
+	}
+
+	dueNow(m)
+	m.warmPriority()
+	if len(m.priority) != 0 {
+		t.Errorf("queued %d pages after recovery, want none", len(m.priority))
+	}
+	if got := content(t, m, "/about:en"); got != "<p>about</p>" {
+		t.Errorf("content = %q, want the warmed page", got)
+	}
+}
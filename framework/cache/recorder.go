@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+// maxPooledRecorderSize is the largest body buffer kept in the pool.
+// Recorders that grew beyond it are dropped so one huge page doesn't pin memory.
+const maxPooledRecorderSize = 1 << 20
+
+// recorderPool holds reusable recorders to avoid per-request buffer allocations.
+var recorderPool = sync.Pool{
+	New: func() interface{} {
+		return &Recorder{
+			ownHeader:  make(http.Header),
+			statusCode: http.StatusOK,
+		}
+	},
+}
+
+// Recorder is a reusable http.ResponseWriter that buffers the status code,
+// headers and body of a response so it can be inspected before being sent or cached.
+// Obtain one with AcquireRecorder and return it with ReleaseRecorder; the bytes
+// returned by Bytes are only valid until the recorder is released.
+type Recorder struct {
+	header      http.Header // Headers written by the handler
+	ownHeader   http.Header // Header map owned by the recorder, reused across uses
+	body        bytes.Buffer
+	statusCode  int
+	wroteHeader bool
+}
+
+// AcquireRecorder returns a reset recorder from the pool.
+// If header is non-nil, handler headers are written straight into it
+// (typically the real ResponseWriter's header map); otherwise the recorder
+// keeps its own header map.
+func AcquireRecorder(header http.Header) *Recorder {
+	rec := recorderPool.Get().(*Recorder)
+	if header != nil {
+		rec.header = header
+	} else {
+		rec.header = rec.ownHeader
+	}
+	return rec
+}
+
+// ReleaseRecorder resets the recorder and returns it to the pool.
+func ReleaseRecorder(rec *Recorder) {
+	if rec.body.Cap() > maxPooledRecorderSize {
+		return
+	}
+	rec.Reset()
+	recorderPool.Put(rec)
+}
+
+// Reset clears all captured data so the recorder can be reused.
+func (r *Recorder) Reset() {
+	r.body.Reset()
+	r.statusCode = http.StatusOK
+	r.wroteHeader = false
+	clear(r.ownHeader)
+	r.header = r.ownHeader
+}
+
+// Header returns the header map the handler writes to.
+func (r *Recorder) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader captures the status code without writing to the underlying writer.
+func (r *Recorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+}
+
+// Write captures the response body without writing to the underlying writer.
+func (r *Recorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+	}
+	return r.body.Write(b)
+}
+
+// StatusCode returns the captured status code (200 if none was written).
+func (r *Recorder) StatusCode() int {
+	return r.statusCode
+}
+
+// Bytes returns the captured body. The slice is reused after ReleaseRecorder,
+// so copy it before retaining it.
+func (r *Recorder) Bytes() []byte {
+	return r.body.Bytes()
+}
+
+// HeaderSubset returns a copy of the captured headers limited to the given names.
+func (r *Recorder) HeaderSubset(names ...string) http.Header {
+	subset := make(http.Header, len(names))
+	for _, name := range names {
+		if values := r.header.Values(name); len(values) > 0 {
+			subset[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+	return subset
+}
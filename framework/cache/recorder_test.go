@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorderOwnHeader(t *testing.T) {
+	rec := AcquireRecorder(nil)
+	defer ReleaseRecorder(rec)
+
+	// Handlers set headers on recorders acquired without a header map
+	rec.Header().Set("Content-Type", "text/html")
+	if got := rec.Header().Get("Content-Type"); got != "text/html" {
+		t.Errorf("Content-Type = %q, want text/html", got)
+	}
+}
+
+func TestRecorderSharedHeader(t *testing.T) {
+	header := make(http.Header)
+	rec := AcquireRecorder(header)
+	defer ReleaseRecorder(rec)
+
+	rec.Header().Set("X-Test", "1")
+	if header.Get("X-Test") != "1" {
+		t.Error("header not written to the supplied map")
+	}
+}
+
+func TestRecorderReuseStartsClean(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		rec := AcquireRecorder(nil)
+		if rec.StatusCode() != http.StatusOK || len(rec.Bytes()) != 0 || len(rec.Header()) != 0 {
+			t.Fatalf("use %d: recorder carries data from a previous use: status %d, body %q, header %v",
+				i, rec.StatusCode(), rec.Bytes(), rec.Header())
+		}
+
+		rec.Header().Set("Set-Cookie", "session=secret")
+		rec.WriteHeader(http.StatusNotFound)
+		rec.Write([]byte("<p>private page</p>"))
+		ReleaseRecorder(rec)
+	}
+}
+
+func BenchmarkRecorder(b *testing.B) {
+	body := bytes.Repeat([]byte("<p>page content</p>"), 500)
+	handler := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rec := AcquireRecorder(nil)
+			handler(rec)
+			ReleaseRecorder(rec)
+		}
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			handler(httptest.NewRecorder())
+		}
+	})
+}
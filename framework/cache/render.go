@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrRenderPanic is returned by GetOrRender when the render function panics.
+var ErrRenderPanic = errors.New("render panicked")
+
+// RenderSource tells where content returned by GetOrRenderSource came from.
+type RenderSource string
+
+const (
+	RenderSourceCache    RenderSource = "cache"    // A fresh cache entry
+	RenderSourceStale    RenderSource = "stale"    // A stale entry, served because re-rendering failed
+	RenderSourceRendered RenderSource = "rendered" // A new render (possibly shared with concurrent callers)
+)
+
+// GetOrRender returns the cached content for a key, or calls render on a miss
+// (or stale entry), stores the result under the given strategy and returns it.
+// Concurrent misses for the same key share a single render.
+// Content rejected by the validator or failing to store is still returned.
+// A panicking render is reported to every caller as ErrRenderPanic, and
+// tombstoned keys are never rendered but reported as ErrGone.
+func (m *Manager) GetOrRender(ctx context.Context, cacheKey, strategy string, render func() ([]byte, error)) ([]byte, error) {
+	content, _, err := m.GetOrRenderSource(ctx, cacheKey, strategy, render)
+	return content, err
+}
+
+// GetOrRenderSource is GetOrRender that also reports where the content came from,
+// so handlers can branch on fresh renders (e.g. to emit analytics).
+// When re-rendering a stale entry fails, the stale content is returned instead of the error.
+func (m *Manager) GetOrRenderSource(ctx context.Context, cacheKey, strategy string, render func() ([]byte, error)) ([]byte, RenderSource, error) {
+	if content, ok := m.freshContent(cacheKey); ok {
+		return content, RenderSourceCache, nil
+	}
+
+	return m.renders.do(ctx, cacheKey, func() ([]byte, RenderSource, error) {
+		// A concurrent render may have stored the page in the meantime
+		if content, ok := m.freshContent(cacheKey); ok {
+			return content, RenderSourceCache, nil
+		}
+
+		if m.IsTombstoned(cacheKey) {
+			return nil, "", newError("render", cacheKey, ErrGone, ErrGone)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+
+		content, err := m.safeRender(cacheKey, render)
+		if err != nil {
+			if stale, ok := m.staleContent(cacheKey); ok {
+				m.logger.Warn("render failed, serving stale content",
+					slog.String("key", cacheKey),
+					slog.String("error", err.Error()),
+				)
+				return stale, RenderSourceStale, nil
+			}
+			return nil, "", err
+		}
+
+		if strategy != "" && strategy != "dynamic" {
+			if err := m.Set(cacheKey, content, strategy, ""); err != nil && !errors.Is(err, ErrRejected) && !errors.Is(err, ErrGone) {
+				m.logger.Warn("failed to cache rendered content",
+					slog.String("key", cacheKey),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+
+		return content, RenderSourceRendered, nil
+	})
+}
+
+// safeRender calls render, turning a panic into an ErrRenderPanic error so
+// callers sharing the render get an error instead of empty content.
+func (m *Manager) safeRender(cacheKey string, render func() ([]byte, error)) (content []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("render panicked",
+				slog.String("key", cacheKey),
+				slog.Any("panic", r),
+			)
+			content, err = nil, fmt.Errorf("%w: %v", ErrRenderPanic, r)
+		}
+	}()
+	return render()
+}
+
+// freshContent returns the decompressed content of a fresh, non-redirect entry.
+func (m *Manager) freshContent(cacheKey string) ([]byte, bool) {
+	return m.entryContent(cacheKey, false)
+}
+
+// staleContent returns the decompressed content of a stale, non-redirect entry.
+func (m *Manager) staleContent(cacheKey string) ([]byte, bool) {
+	return m.entryContent(cacheKey, true)
+}
+
+// entryContent returns the decompressed content of a non-redirect entry
+// whose staleness matches stale.
+func (m *Manager) entryContent(cacheKey string, stale bool) ([]byte, bool) {
+	entry, ok := m.Get(cacheKey)
+	if !ok || entry.IsStale() != stale {
+		return nil, false
+	}
+	if status, _ := entry.Redirect(); status != 0 {
+		return nil, false
+	}
+
+	content, err := GetDecompressedContent(entry)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// renderCall is an in-flight render shared by concurrent callers.
+type renderCall struct {
+	done      chan struct{}
+	content   []byte
+	source    RenderSource
+	err       error
+	cancelled bool // err came from the leading caller's cancelled context
+}
+
+// renderGroup coalesces concurrent renders of the same key.
+// The zero value is ready to use.
+type renderGroup struct {
+	mu        sync.Mutex
+	calls     map[string]*renderCall
+	coalesced atomic.Int64 // Callers served another caller's render
+}
+
+// do runs fn once per key at a time; callers arriving while it runs wait for
+// its result, or return early when their context is cancelled. When the render
+// was abandoned because the caller running it went away, waiters still
+// interested in the result render again rather than sharing its context error.
+func (g *renderGroup) do(ctx context.Context, key string, fn func() ([]byte, RenderSource, error)) ([]byte, RenderSource, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*renderCall)
+	}
+	for {
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			if call.cancelled && ctx.Err() == nil {
+				g.mu.Lock()
+				continue
+			}
+			g.coalesced.Add(1)
+			return call.content, call.source, call.err
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+
+	call := &renderCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.content, call.source, call.err = fn()
+	call.cancelled = call.err != nil && ctx.Err() != nil && errors.Is(call.err, ctx.Err())
+	return call.content, call.source, call.err
+}
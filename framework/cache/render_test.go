@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrRenderPanicReachesAllWaiters(t *testing.T) {
+	m := newTestManager(t)
+
+	const callers = 8
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	render := func() ([]byte, error) {
+		once.Do(func() { close(started) })
+		<-release
+		panic("template exploded")
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, callers)
+	contents := make([][]byte, callers)
+	call := func(i int) {
+		defer wg.Done()
+		contents[i], errs[i] = m.GetOrRender(context.Background(), "/boom:en", "static", render)
+	}
+
+	wg.Add(1)
+	go call(0)
+	<-started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go call(i)
+	}
+
+	// Give the other callers time to join the in-flight render
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if !errors.Is(err, ErrRenderPanic) {
+			t.Errorf("caller %d: err = %v, want ErrRenderPanic", i, err)
+		}
+		if contents[i] != nil {
+			t.Errorf("caller %d: content = %q, want nil", i, contents[i])
+		}
+	}
+	if got := m.Stats().Coalesced; got != callers-1 {
+		t.Errorf("Coalesced = %d, want %d", got, callers-1)
+	}
+	if _, ok := m.Get("/boom:en"); ok {
+		t.Error("panicked render was cached")
+	}
+}
+
+func TestGetOrRender(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static")
+
+	var renders atomic.Int32
+	render := func() ([]byte, error) {
+		renders.Add(1)
+		return []byte("<p>about</p>"), nil
+	}
+
+	// A miss renders and stores the page; the next call is served from the cache
+	for i := 0; i < 2; i++ {
+		got, err := m.GetOrRender(context.Background(), "/about:en", "static", render)
+		if err != nil {
+			t.Fatalf("call %d: GetOrRender: %v", i, err)
+		}
+		if string(got) != "<p>about</p>" {
+			t.Errorf("call %d: content = %q", i, got)
+		}
+	}
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1", got)
+	}
+
+	// Render errors reach the caller and nothing is cached
+	failure := errors.New("database down")
+	if _, err := m.GetOrRender(context.Background(), "/news:en", "static", func() ([]byte, error) {
+		return nil, failure
+	}); !errors.Is(err, failure) {
+		t.Errorf("err = %v, want %v", err, failure)
+	}
+	if _, ok := m.Get("/news:en"); ok {
+		t.Error("failed render was cached")
+	}
+}
+
+func TestGetOrRenderCoalescesConcurrentMisses(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static")
+
+	const callers = 8
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var renders atomic.Int32
+	render := func() ([]byte, error) {
+		if renders.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return []byte("<p>slow</p>"), nil
+	}
+
+	var wg sync.WaitGroup
+	contents := make([][]byte, callers)
+	call := func(i int) {
+		defer wg.Done()
+		contents[i], _ = m.GetOrRender(context.Background(), "/slow:en", "static", render)
+	}
+
+	wg.Add(1)
+	go call(0)
+	<-started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go call(i)
+	}
+
+	// Give the other callers time to join the in-flight render
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1", got)
+	}
+	for i, content := range contents {
+		if string(content) != "<p>slow</p>" {
+			t.Errorf("caller %d: content = %q", i, content)
+		}
+	}
+}
+
+func TestCoalescedCounter(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	render := func() ([]byte, error) {
+		close(started)
+		<-release
+		return []byte("<p>slow</p>"), nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.GetOrRender(context.Background(), "/slow:en", "static", render)
+	}()
+	<-started
+
+	// One waiter receives the shared render; a cancelled one gives up and isn't counted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.GetOrRender(ctx, "/slow:en", "static", render); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller err = %v, want context.Canceled", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.GetOrRender(context.Background(), "/slow:en", "static", render)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// Cache hits are not coalesced renders
+	m.GetOrRender(context.Background(), "/slow:en", "static", render)
+
+	if got := m.Stats().Coalesced; got != 1 {
+		t.Errorf("Coalesced = %d, want 1", got)
+	}
+}
+
+func TestGetOrRenderSource(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static")
+
+	body := "<p>v1</p>"
+	var failure error
+	render := func() ([]byte, error) {
+		return []byte(body), failure
+	}
+
+	steps := []struct {
+		name    string
+		before  func()
+		want    string
+		wantSrc RenderSource
+	}{
+		{"miss", func() {}, "<p>v1</p>", RenderSourceRendered},
+		{"hit", func() { body = "<p>v2</p>" }, "<p>v1</p>", RenderSourceCache},
+		{"stale, render fails", func() { m.MarkAllStale(false); failure = errors.New("down") }, "<p>v1</p>", RenderSourceStale},
+		{"stale, render succeeds", func() { failure = nil }, "<p>v2</p>", RenderSourceRendered},
+	}
+
+	for _, step := range steps {
+		step.before()
+		content, source, err := m.GetOrRenderSource(context.Background(), "/about:en", "static", render)
+		if err != nil {
+			t.Fatalf("%s: GetOrRenderSource: %v", step.name, err)
+		}
+		if source != step.wantSrc {
+			t.Errorf("%s: source = %q, want %q", step.name, source, step.wantSrc)
+		}
+		if string(content) != step.want {
+			t.Errorf("%s: content = %q, want %q", step.name, content, step.want)
+		}
+	}
+}
+
+func TestGetOrRenderLeaderCancelled(t *testing.T) {
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static")
+
+	// The first caller's render stops when its client goes away
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, err := m.GetOrRender(leaderCtx, "/slow:en", "static", func() ([]byte, error) {
+			close(started)
+			<-leaderCtx.Done()
+			return nil, leaderCtx.Err()
+		})
+		leaderDone <- err
+	}()
+	<-started
+
+	waiterDone := make(chan []byte, 1)
+	go func() {
+		content, err := m.GetOrRender(context.Background(), "/slow:en", "static", func() ([]byte, error) {
+			return []byte("<p>slow</p>"), nil
+		})
+		if err != nil {
+			t.Errorf("waiter GetOrRender: %v", err)
+		}
+		waiterDone <- content
+	}()
+
+	// Give the waiter time to join the in-flight render
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error = %v, want context.Canceled", err)
+	}
+	if got := <-waiterDone; string(got) != "<p>slow</p>" {
+		t.Errorf("waiter content = %q, want its own render", got)
+	}
+}
+
+func TestGetOrRenderTombstoned(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.Tombstone("/gone", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+
+	var renders atomic.Int32
+	_, err := m.GetOrRender(context.Background(), "/gone:en", "static", func() ([]byte, error) {
+		renders.Add(1)
+		return []byte("<p>gone</p>"), nil
+	})
+	if !errors.Is(err, ErrGone) {
+		t.Errorf("GetOrRender error = %v, want ErrGone", err)
+	}
+	if got := renders.Load(); got != 0 {
+		t.Errorf("renders = %d, want 0", got)
+	}
+
+	// Direct writes are refused too, so the page never comes back on disk
+	if err := m.SetSync("/gone:en", []byte("<p>gone</p>"), "static", "/gone"); !errors.Is(err, ErrGone) {
+		t.Errorf("SetSync error = %v, want ErrGone", err)
+	}
+	if m.storage.Exists("/gone:en") {
+		t.Error("tombstoned page written to disk")
+	}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// WriteRetryConfig configures retries of disk writes on transient errors.
+type WriteRetryConfig struct {
+	Attempts  int           // Total attempts per file, including the first (1 disables retries)
+	BaseDelay time.Duration // Backoff before the first retry, doubled per attempt
+}
+
+// DefaultWriteRetryConfig returns default write retry configuration.
+func DefaultWriteRetryConfig() WriteRetryConfig {
+	return WriteRetryConfig{
+		Attempts:  3,
+		BaseDelay: 10 * time.Millisecond,
+	}
+}
+
+// fileWriter writes a file; os.WriteFile outside of tests.
+type fileWriter func(name string, data []byte, perm os.FileMode) error
+
+// SetWriteRetry configures retries of disk writes on transient errors.
+func (s *Storage) SetWriteRetry(config WriteRetryConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retry = config
+}
+
+// writeFile writes a file, retrying transient errors with exponential backoff.
+// Callers must hold s.mu for writing; it is released while backing off so
+// reads and other writes aren't stalled, so state read under the lock before
+// the call may have changed when it returns. Writers of cache key files use
+// trackWrite so a Delete during the backoff is not undone.
+func (s *Storage) writeFile(name string, data []byte, perm os.FileMode) error {
+	attempts := s.retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := s.retry.BaseDelay
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = s.writer(name, data, perm); err == nil || !isRetryable(err) {
+			return err
+		}
+		if attempt < attempts {
+			s.mu.Unlock()
+			time.Sleep(delay)
+			s.mu.Lock()
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// pendingWrite tracks in-progress writes to one cache key.
+type pendingWrite struct {
+	writers     int    // Writes to the key currently in progress
+	deletes     uint64 // Incremented by Delete while writes are in progress
+	rewrittenAt uint64 // Value of deletes when the most recent write began
+}
+
+// trackWrite registers a write to cacheKey and returns the function that
+// ends it. If the key is deleted while the write backs off, and no newer write
+// began since, the ending function removes the given files again.
+// Callers must hold s.mu for writing, also when calling the returned function.
+func (s *Storage) trackWrite(cacheKey string) func(paths ...string) {
+	if s.pending == nil {
+		s.pending = make(map[string]*pendingWrite)
+	}
+	p := s.pending[cacheKey]
+	if p == nil {
+		p = &pendingWrite{}
+		s.pending[cacheKey] = p
+	}
+	p.writers++
+	p.rewrittenAt = p.deletes
+	started := p.deletes
+
+	return func(paths ...string) {
+		if p.deletes != started && p.rewrittenAt != p.deletes {
+			for _, path := range paths {
+				_ = os.Remove(path)
+			}
+		}
+		p.writers--
+		if p.writers == 0 {
+			delete(s.pending, cacheKey)
+		}
+	}
+}
+
+// isRetryable reports whether a write error may succeed if attempted again.
+// Permission and path errors fail immediately.
+func isRetryable(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) ||
+		errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EBUSY) ||
+		errors.Is(err, syscall.EIO)
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// failingWriter fails the first failures writes with err, then writes normally.
+func failingWriter(failures int32, err error, calls *atomic.Int32) fileWriter {
+	return func(name string, data []byte, perm os.FileMode) error {
+		if calls.Add(1) <= failures {
+			return err
+		}
+		return os.WriteFile(name, data, perm)
+	}
+}
+
+func TestWriteRetriesTransientErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		err      error
+		wantErr  bool
+		wantCall int32
+	}{
+		{"transient error recovers", 1, syscall.EIO, false, 3},
+		{"persistent transient error", 10, syscall.ENOSPC, true, 3},
+		{"permanent error", 1, syscall.EACCES, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t.TempDir())
+			s.SetWriteRetry(WriteRetryConfig{Attempts: 3, BaseDelay: time.Millisecond})
+			var calls atomic.Int32
+			s.writer = failingWriter(tt.failures, tt.err, &calls)
+
+			err := s.Write("/page:en", []byte("br"), []byte("html"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Write error = %v, want %v", err, tt.err)
+			}
+			if got := calls.Load(); got != tt.wantCall {
+				t.Errorf("writer calls = %d, want %d", got, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestWriteRetryReleasesLock(t *testing.T) {
+	s := newStorage(t.TempDir())
+	s.SetWriteRetry(WriteRetryConfig{Attempts: 2, BaseDelay: 500 * time.Millisecond})
+
+	backingOff := make(chan struct{})
+	var calls atomic.Int32
+	s.writer = func(name string, data []byte, perm os.FileMode) error {
+		if calls.Add(1) == 1 {
+			close(backingOff)
+			return syscall.EIO
+		}
+		return os.WriteFile(name, data, perm)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Write("/slow:en", []byte("br"), []byte("html")) }()
+
+	// Reads don't wait for the write to finish backing off
+	<-backingOff
+	start := time.Now()
+	s.Exists("/other:en")
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("read blocked for %v by a write backing off", elapsed)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+func TestWriteRetryKeepsFilesConsistent(t *testing.T) {
+	s := newStorage(t.TempDir())
+	s.SetWriteRetry(WriteRetryConfig{Attempts: 2, BaseDelay: 100 * time.Millisecond})
+
+	// The first HTML write of the old content fails once
+	backingOff := make(chan struct{})
+	var once sync.Once
+	s.writer = func(name string, data []byte, perm os.FileMode) error {
+		retry := false
+		if strings.HasSuffix(name, ".html") && string(data) == "old html" {
+			once.Do(func() { retry = true })
+		}
+		if retry {
+			close(backingOff)
+			return syscall.EIO
+		}
+		return os.WriteFile(name, data, perm)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Write("/page:en", []byte("old br"), []byte("old html")) }()
+
+	// Another write lands while the first one backs off
+	<-backingOff
+	if err := s.Write("/page:en", []byte("new br"), []byte("new html")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	br, _ := s.ReadBrotli("/page:en")
+	html, _ := s.ReadHTML("/page:en")
+	if strings.TrimSuffix(string(br), " br") != strings.TrimSuffix(string(html), " html") {
+		t.Errorf("files disagree: br %q, html %q", br, html)
+	}
+}
+
+func TestWriteRetryRespectsDelete(t *testing.T) {
+	tests := []struct {
+		name      string
+		rewrite   bool // Write the key again after deleting it
+		wantExist bool
+	}{
+		{"delete", false, false},
+		{"delete then write", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t.TempDir())
+			s.SetWriteRetry(WriteRetryConfig{Attempts: 2, BaseDelay: 100 * time.Millisecond})
+
+			backingOff := make(chan struct{})
+			var calls atomic.Int32
+			s.writer = func(name string, data []byte, perm os.FileMode) error {
+				if calls.Add(1) == 1 {
+					close(backingOff)
+					return syscall.EIO
+				}
+				return os.WriteFile(name, data, perm)
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- s.Write("/page:en", []byte("br"), []byte("html")) }()
+
+			// The key is deleted while the write backs off
+			<-backingOff
+			if err := s.Delete("/page:en"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if tt.rewrite {
+				if err := s.Write("/page:en", []byte("br"), []byte("html")); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := <-done; err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			for _, ext := range []string{".br", ".html"} {
+				_, err := os.Stat(s.pathFor("/page:en", ext))
+				if exists := err == nil; exists != tt.wantExist {
+					t.Errorf("%s file exists = %v, want %v", ext, exists, tt.wantExist)
+				}
+			}
+			if len(s.pending) != 0 {
+				t.Errorf("pending writes = %d, want none", len(s.pending))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot writes the persisted cache as a tar stream to w, e.g. for online
+// backups. See Storage.Snapshot. Memory-only managers have nothing to snapshot.
+func (m *Manager) Snapshot(w io.Writer) error {
+	if m.diskless {
+		return newError("snapshot cache", "", ErrStorage, errors.New("cache storage is unavailable"))
+	}
+	return m.storage.Snapshot(w)
+}
+
+// Snapshot writes every cache file as a tar stream to w without blocking
+// writes for the whole operation: the lock is only held while listing the
+// directory and while reading each file, so every archived file is complete.
+// Files deleted after the listing are skipped.
+func (s *Storage) Snapshot(w io.Writer) error {
+	s.mu.RLock()
+	var files []string
+	err := filepath.WalkDir(s.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	baseDir := s.baseDir
+	s.mu.RUnlock()
+
+	if err != nil {
+		return newFileError("read cache directory", "", err)
+	}
+
+	archive := tar.NewWriter(w)
+	for _, path := range files {
+		data, modTime, err := s.readForSnapshot(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return newFileError("read cache file", "", err)
+		}
+
+		name, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to name snapshot file: %w", err)
+		}
+
+		header := &tar.Header{
+			Name:    filepath.ToSlash(name),
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: modTime,
+		}
+		if err := archive.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		if _, err := archive.Write(data); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// readForSnapshot reads one file and its modification time under the read lock,
+// so it never observes a write in progress.
+func (s *Storage) readForSnapshot(path string) ([]byte, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return data, info.ModTime(), nil
+}
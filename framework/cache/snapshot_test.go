@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotDuringWrites(t *testing.T) {
+	s := newStorage(t.TempDir())
+	keys := []string{"/about:en", "/contact:en", "/blog:en"}
+
+	// Each version is larger than the last, so a torn read cannot match any of them
+	versions := make([]string, 20)
+	for i := range versions {
+		versions[i] = fmt.Sprintf("<p>%s</p>", strings.Repeat(fmt.Sprint(i), 1000*(i+1)))
+	}
+	write := func(key, html string) {
+		compressed, err := CompressBrotli([]byte(html))
+		if err != nil {
+			t.Errorf("CompressBrotli: %v", err)
+			return
+		}
+		if err := s.Write(key, compressed, []byte(html)); err != nil {
+			t.Errorf("Write %q: %v", key, err)
+		}
+	}
+	for _, key := range keys {
+		write(key, versions[0])
+	}
+
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, html := range versions[1:] {
+				write(key, html)
+			}
+		}()
+	}
+
+	var snapshots [][]byte
+	for range 5 {
+		var buf bytes.Buffer
+		if err := s.Snapshot(&buf); err != nil {
+			t.Fatalf("Snapshot: %v", err)
+		}
+		snapshots = append(snapshots, buf.Bytes())
+	}
+	wg.Wait()
+
+	known := make(map[string]bool)
+	for _, html := range versions {
+		known[html] = true
+	}
+	for _, snapshot := range snapshots {
+		files := 0
+		archive := tar.NewReader(bytes.NewReader(snapshot))
+		for {
+			header, err := archive.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read snapshot: %v", err)
+			}
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				t.Fatalf("read %s: %v", header.Name, err)
+			}
+			files++
+
+			html := data
+			if path.Ext(header.Name) == ".br" {
+				if html, err = DecompressBrotli(data); err != nil {
+					t.Errorf("%s is not a complete brotli file: %v", header.Name, err)
+					continue
+				}
+			}
+			if !known[string(html)] {
+				t.Errorf("%s holds a partially written page (%d bytes)", header.Name, len(html))
+			}
+		}
+		if files != 2*len(keys) {
+			t.Errorf("snapshot has %d files, want %d", files, 2*len(keys))
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RebuildAllStaged rebuilds all caches into a staging directory and, on success,
+// atomically promotes it over the live cache directory. Readers keep being served
+// the previous cache until the promote completes.
+func (m *Manager) RebuildAllStaged(ctx context.Context, config RebuildConfig) (int, error) {
+	staging, err := m.storage.NewStaging()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create staging storage: %w", err)
+	}
+
+	// Build into a detached manager so live entries stay untouched
+	staged := m.detached(staging)
+	m.tombstones.Range(func(key, value interface{}) bool {
+		staged.tombstones.Store(key, value)
+		return true
+	})
+
+	config.ForceRebuild = true
+	count, failures, err := staged.rebuildCaches(ctx, config, "")
+	if err != nil {
+		_ = os.RemoveAll(staging.baseDir)
+		return 0, err
+	}
+
+	// A partial rebuild would replace good pages with nothing; keep the live cache
+	if len(failures) > 0 {
+		_ = os.RemoveAll(staging.baseDir)
+		return 0, fmt.Errorf("failed to rebuild %d pages, keeping the live cache: %w", len(failures), failures[0].Err)
+	}
+
+	// Parameterized pages are only cached on demand, so carry them over
+	carried, err := m.carryParameterized(staging, config)
+	if err != nil {
+		_ = os.RemoveAll(staging.baseDir)
+		return 0, fmt.Errorf("failed to stage parameterized pages: %w", err)
+	}
+
+	// Carry tombstones over so they survive the swap
+	var tombstoneErr error
+	staged.tombstones.Range(func(_, id interface{}) bool {
+		tombstoneErr = staging.WriteTombstone(id.(string))
+		return tombstoneErr == nil
+	})
+	if tombstoneErr != nil {
+		_ = os.RemoveAll(staging.baseDir)
+		return 0, fmt.Errorf("failed to stage tombstones: %w", tombstoneErr)
+	}
+
+	if err := m.storage.Promote(staging); err != nil {
+		_ = os.RemoveAll(staging.baseDir)
+		return 0, fmt.Errorf("failed to promote staged cache: %w", err)
+	}
+
+	// Swap memory over to the staged set, dropping keys that no longer exist
+	entries := carried
+	staged.entryMap().Range(func(key, value interface{}) bool {
+		entries[key.(string)] = value.(*Entry)
+		return true
+	})
+	if err := m.ReplaceAll(entries, false); err != nil {
+		return 0, err
+	}
+
+	m.logger.Info("staged cache promoted",
+		slog.Int("total_cached", count),
+	)
+
+	return count, nil
+}
+
+// carryParameterized copies the live entries of parameterized routes into the
+// staging storage and returns those held in memory, keyed by cache key.
+func (m *Manager) carryParameterized(staging *Storage, config RebuildConfig) (map[string]*Entry, error) {
+	routes, err := loadRoutes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*Entry)
+	for _, route := range routes {
+		if route.Strategy == "dynamic" || !strings.Contains(route.Canonical, "{") {
+			continue
+		}
+
+		for _, lang := range config.Languages {
+			cacheKey := GetCacheKey(route.Canonical, lang, nil)
+			if value, ok := m.entryMap().Load(cacheKey); ok {
+				entries[cacheKey] = value.(*Entry)
+			}
+			if m.diskless {
+				continue
+			}
+			if err := m.storage.copyTo(staging, cacheKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// copyTo copies the files of a cache key into another storage, keeping their
+// modification times so freshness checks are unaffected. Missing keys are skipped.
+func (s *Storage) copyTo(dst *Storage, cacheKey string) error {
+	compressed, err := s.ReadBrotli(cacheKey)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	html, err := s.ReadHTML(cacheKey)
+	if err != nil {
+		return err
+	}
+	modTime, err := s.ModTime(cacheKey)
+	if err != nil {
+		return err
+	}
+
+	if err := dst.Write(cacheKey, compressed, html); err != nil {
+		return err
+	}
+
+	dst.mu.RLock()
+	paths := []string{dst.pathFor(cacheKey, ".br"), dst.pathFor(cacheKey, ".html")}
+	dst.mu.RUnlock()
+	for _, path := range paths {
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			return newError("copy cache file", cacheKey, ErrStorage, err)
+		}
+	}
+
+	meta, err := s.ReadMeta(cacheKey)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return dst.WriteMeta(cacheKey, meta)
+}
+
+// detached returns a manager writing to storage with the same configuration as m
+// but none of its entries, subscribers or write callbacks. Memory budgets are not
+// carried over, since every staged entry must survive until the swap.
+func (m *Manager) detached(storage *Storage) *Manager {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return &Manager{
+		storage:      storage,
+		logger:       m.logger,
+		router:       m.router,
+		memoryOnly:   m.memoryOnly,
+		sampleRate:   m.sampleRate,
+		sampler:      m.sampler,
+		validator:    m.validator,
+		ratioWarn:    m.ratioWarn,
+		minFresh:     m.minFresh,
+		contentVer:   m.contentVer,
+		diskless:     m.diskless,
+		jitter:       m.jitter,
+		cacheable:    m.cacheable,
+		revalidators: m.revalidators,
+	}
+}
+
+// NewStaging creates an empty storage in a sibling staging directory.
+// Any leftover staging directory from a failed rebuild is removed first.
+func (s *Storage) NewStaging() (*Storage, error) {
+	s.mu.RLock()
+	stagingDir := filepath.Clean(s.baseDir) + ".staging"
+	languageDirs := s.languageDirs
+	retry := s.retry
+	writer := s.writer
+	indexed := s.metaIndex != nil
+	s.mu.RUnlock()
+
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return nil, fmt.Errorf("failed to remove old staging directory: %w", err)
+	}
+
+	staging, err := NewStorage(stagingDir)
+	if err != nil {
+		return nil, err
+	}
+	staging.languageDirs = languageDirs
+	staging.retry = retry
+	staging.writer = writer
+	if indexed {
+		staging.metaIndex = make(map[string]EntryMeta)
+	}
+
+	return staging, nil
+}
+
+// Promote swaps the staging storage's directory into place of this storage's
+// directory, along with its metadata index. File operations are blocked for the
+// duration of the swap.
+func (s *Storage) Promote(staging *Storage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	staging.mu.Lock()
+	defer staging.mu.Unlock()
+
+	liveDir := filepath.Clean(s.baseDir)
+	backupDir := liveDir + ".old"
+
+	if err := os.RemoveAll(backupDir); err != nil {
+		return fmt.Errorf("failed to remove old backup directory: %w", err)
+	}
+
+	if err := os.Rename(liveDir, backupDir); err != nil {
+		return fmt.Errorf("failed to move live cache aside: %w", err)
+	}
+
+	if err := os.Rename(staging.baseDir, liveDir); err != nil {
+		// Restore the previous cache so the live directory is never missing
+		_ = os.Rename(backupDir, liveDir)
+		return fmt.Errorf("failed to move staged cache into place: %w", err)
+	}
+
+	staging.baseDir = liveDir
+
+	// The in-memory index described the previous directory; adopt the staged one
+	s.metaIndex = staging.metaIndex
+	s.metaAppends = staging.metaAppends
+
+	// The swap succeeded; a backup that can't be removed now is removed by the next promote
+	_ = os.RemoveAll(backupDir)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+)
+
+var aboutRoute = RouteConfig{
+	Canonical: "/about",
+	Paths:     map[string]string{"en": "/about"},
+	Strategy:  "static",
+}
+
+func TestRebuildAllStagedPromotesNewContent(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetSync("/about:en", []byte("<p>old</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	// While the staged rebuild renders, readers still get the old cache
+	var during string
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		during = content(t, m, "/about:en")
+		w.Write([]byte("<p>new</p>"))
+	})
+
+	if _, err := m.RebuildAllStaged(context.Background(), testRebuildConfig(t, router, []string{"en"}, aboutRoute)); err != nil {
+		t.Fatalf("RebuildAllStaged: %v", err)
+	}
+
+	if during != "<p>old</p>" {
+		t.Errorf("content during rebuild = %q, want old content", during)
+	}
+	if got := content(t, m, "/about:en"); got != "<p>new</p>" {
+		t.Errorf("content after promote = %q, want new content", got)
+	}
+	html, err := m.storage.ReadHTML("/about:en")
+	if err != nil || string(html) != "<p>new</p>" {
+		t.Errorf("disk content after promote = %q, %v", html, err)
+	}
+}
+
+func TestRebuildAllStagedKeepsConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetContentVersion("v1")
+
+	config := testRebuildConfig(t, pageRouter("<p>about</p>"), []string{"en"}, aboutRoute)
+	if _, err := m.RebuildAllStaged(context.Background(), config); err != nil {
+		t.Fatalf("RebuildAllStaged: %v", err)
+	}
+
+	// Promoted sidecars carry the content version, so a restart keeps them fresh
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	restarted.SetContentVersion("v1")
+	entry, ok := restarted.Get("/about:en")
+	if !ok {
+		t.Fatal("promoted entry not found after restart")
+	}
+	if entry.IsStale() {
+		t.Error("promoted entry is stale after restart")
+	}
+}
+
+func TestRebuildAllStagedMetadataIndex(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetMetadataIndex(true); err != nil {
+		t.Fatalf("SetMetadataIndex: %v", err)
+	}
+	if err := m.SetSync("/about:en", []byte("<p>old</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	config := testRebuildConfig(t, pageRouter("<p>new</p>"), []string{"en"}, aboutRoute)
+	if _, err := m.RebuildAllStaged(context.Background(), config); err != nil {
+		t.Fatalf("RebuildAllStaged: %v", err)
+	}
+
+	entry, ok := m.Get("/about:en")
+	if !ok {
+		t.Fatal("entry not found after promote")
+	}
+	_, etag, _ := entry.Snapshot()
+	meta, err := m.storage.ReadMeta("/about:en")
+	if err != nil {
+		t.Fatalf("ReadMeta: %v", err)
+	}
+	if meta.ETag != etag {
+		t.Errorf("indexed ETag = %q, want %q of the promoted entry", meta.ETag, etag)
+	}
+}
+
+func TestRebuildAllStagedKeepsLiveCacheOnFailure(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetSync("/about:en", []byte("<p>old</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	config := testRebuildConfig(t, flakyRouter(1, "<p>new</p>"), []string{"en"}, aboutRoute)
+	if _, err := m.RebuildAllStaged(context.Background(), config); err == nil {
+		t.Fatal("RebuildAllStaged succeeded with a failing page")
+	}
+
+	if got := content(t, m, "/about:en"); got != "<p>old</p>" {
+		t.Errorf("content = %q, want the live page", got)
+	}
+	html, err := m.storage.ReadHTML("/about:en")
+	if err != nil || string(html) != "<p>old</p>" {
+		t.Errorf("disk content = %q, %v, want the live page", html, err)
+	}
+	if _, err := os.Stat(m.storage.baseDir + ".staging"); !os.IsNotExist(err) {
+		t.Errorf("staging directory left behind: %v", err)
+	}
+}
+
+func TestRebuildAllStagedKeepsParameterizedPages(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.SetSync("/blog/{slug}:en", []byte("<p>post</p>"), "incremental", "/blog/hello"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	post := RouteConfig{Canonical: "/blog/{slug}", Paths: map[string]string{"en": "/blog/{slug}"}, Strategy: "incremental"}
+	config := testRebuildConfig(t, pageRouter("<p>about</p>"), []string{"en"}, aboutRoute, post)
+	if _, err := m.RebuildAllStaged(context.Background(), config); err != nil {
+		t.Fatalf("RebuildAllStaged: %v", err)
+	}
+
+	if got := content(t, m, "/blog/{slug}:en"); got != "<p>post</p>" {
+		t.Errorf("parameterized content = %q, want it carried over", got)
+	}
+	html, err := m.storage.ReadHTML("/blog/{slug}:en")
+	if err != nil || string(html) != "<p>post</p>" {
+		t.Errorf("parameterized disk content = %q, %v, want it carried over", html, err)
+	}
+	if got := content(t, m, "/about:en"); got != "<p>about</p>" {
+		t.Errorf("content = %q, want the rebuilt page", got)
+	}
+}
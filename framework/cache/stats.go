@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// defaultCompressionWarnRatio flags pages that shrink by less than 10%,
+	// often a sign of embedded binary or base64 data.
+	defaultCompressionWarnRatio = 0.9
+
+	// compressionWarnMinSize skips the ratio warning for tiny pages,
+	// which never compress well.
+	compressionWarnMinSize = 1024
+)
+
+// EntryInfo describes a cached entry for inspection.
+type EntryInfo struct {
+	Key              string
+	Strategy         string
+	RequestPath      string
+	RenderedAt       time.Time
+	Generation       int64
+	Size             int // Size in memory in bytes (compressed unless incompressible)
+	UncompressedSize int // Original size in bytes, derived from the compression ratio
+	ETag             string
+	CompressionRatio float64
+	Stale            bool
+	Pinned           bool
+	ExpiresAt        time.Time // When an incremental entry's jittered TTL runs out; zero otherwise
+}
+
+// Stats summarizes the in-memory cache.
+type Stats struct {
+	Entries          int
+	Stale            int
+	Tombstones       int
+	ByStrategy       map[string]int
+	CompressedBytes  int64
+	CompressionRatio float64 // Average compression ratio across entries with content
+	Coalesced        int64   // GetOrRender calls served by a concurrent caller's render
+	DroppedEvents    int64   // Events discarded because a subscriber's buffer was full
+	Evicted          int64   // Entries dropped from memory to stay within the memory budget
+}
+
+// RevalidationStats tallies revalidation outcomes for one strategy.
+type RevalidationStats struct {
+	MarkedStale int64     // Entries marked stale by MarkStale/MarkAllStale
+	Rerendered  int64     // Eager re-renders that succeeded
+	Failed      int64     // Eager re-renders that failed
+	LastRun     time.Time // When entries of the strategy were last marked stale
+}
+
+// List returns information about all in-memory entries, sorted by key.
+func (m *Manager) List() []EntryInfo {
+	var infos []EntryInfo
+
+	m.entryMap().Range(func(key, value interface{}) bool {
+		infos = append(infos, value.(*Entry).info(key.(string)))
+		return true
+	})
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+
+	return infos
+}
+
+// ExpiringWithin returns fresh entries whose strategy TTL runs out within d,
+// soonest first, so a scheduler can re-render them before they go stale.
+// Entries that already need revalidation are not included.
+func (m *Manager) ExpiringWithin(d time.Duration) []EntryInfo {
+	deadline := time.Now().Add(d)
+	var infos []EntryInfo
+
+	for _, info := range m.List() {
+		if info.Strategy != "incremental" || info.Stale || info.Pinned {
+			continue
+		}
+		if info.ExpiresAt.Before(time.Now()) || info.ExpiresAt.After(deadline) {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ExpiresAt.Before(infos[j].ExpiresAt)
+	})
+
+	return infos
+}
+
+// Stats returns aggregate statistics about the in-memory cache.
+func (m *Manager) Stats() Stats {
+	stats := Stats{
+		ByStrategy:    make(map[string]int),
+		Coalesced:     m.renders.coalesced.Load(),
+		DroppedEvents: m.droppedEvents.Load(),
+		Evicted:       m.evicted.Load(),
+	}
+
+	var ratioSum float64
+	var ratioCount int
+
+	for _, info := range m.List() {
+		stats.Entries++
+		stats.ByStrategy[info.Strategy]++
+		stats.CompressedBytes += int64(info.Size)
+		if info.Stale {
+			stats.Stale++
+		}
+		if info.CompressionRatio > 0 {
+			ratioSum += info.CompressionRatio
+			ratioCount++
+		}
+	}
+
+	if ratioCount > 0 {
+		stats.CompressionRatio = ratioSum / float64(ratioCount)
+	}
+
+	m.tombstones.Range(func(_, _ interface{}) bool {
+		stats.Tombstones++
+		return true
+	})
+
+	return stats
+}
+
+// Dump writes a human-readable table of all in-memory entries followed by totals,
+// for debugging and support tickets. Content is never decompressed.
+func (m *Manager) Dump(w io.Writer) error {
+	infos := m.List()
+	now := time.Now()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSTRATEGY\tGEN\tAGE\tSTALE\tSIZE\tUNCOMPRESSED\tETAG")
+
+	var size, uncompressedSize int64
+	stale := 0
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%t\t%d\t%d\t%s\n",
+			info.Key,
+			info.Strategy,
+			info.Generation,
+			now.Sub(info.RenderedAt).Truncate(time.Second),
+			info.Stale,
+			info.Size,
+			info.UncompressedSize,
+			info.ETag,
+		)
+		size += int64(info.Size)
+		uncompressedSize += int64(info.UncompressedSize)
+		if info.Stale {
+			stale++
+		}
+	}
+
+	fmt.Fprintf(tw, "TOTAL\t%d entries\t\t\t%d stale\t%d\t%d\t\n", len(infos), stale, size, uncompressedSize)
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write cache dump: %w", err)
+	}
+	return nil
+}
+
+// SetCompressionWarnRatio sets the compression ratio (compressed/uncompressed)
+// above which storing a page logs a warning. Zero disables the warning.
+func (m *Manager) SetCompressionWarnRatio(ratio float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ratioWarn = ratio
+}
+
+// checkCompressionRatio logs a warning when a page compresses poorly.
+func (m *Manager) checkCompressionRatio(cacheKey string, size int, ratio float64) {
+	m.mu.RLock()
+	threshold := m.ratioWarn
+	m.mu.RUnlock()
+
+	if threshold <= 0 || size < compressionWarnMinSize || ratio <= threshold {
+		return
+	}
+
+	m.logger.Warn("cached page compresses poorly",
+		slog.String("key", cacheKey),
+		slog.Int("size", size),
+		slog.Float64("ratio", ratio),
+		slog.Float64("threshold", threshold),
+	)
+}
+
+// info returns a snapshot of the entry for inspection.
+func (e *Entry) info(cacheKey string) EntryInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	uncompressedSize := len(e.Content)
+	if !e.Uncompressed && e.CompressionRatio > 0 {
+		uncompressedSize = int(math.Round(float64(len(e.Content)) / e.CompressionRatio))
+	}
+
+	var expiresAt time.Time
+	if e.Strategy == "incremental" {
+		expiresAt = e.RenderedAt.Add(incrementalTTL + e.ttlJitter)
+	}
+
+	return EntryInfo{
+		Key:              cacheKey,
+		Strategy:         e.Strategy,
+		RequestPath:      e.RequestPath,
+		RenderedAt:       e.RenderedAt,
+		Generation:       e.Generation,
+		Size:             len(e.Content),
+		UncompressedSize: uncompressedSize,
+		ETag:             e.ETag,
+		CompressionRatio: e.CompressionRatio,
+		Stale:            e.IsStale(),
+		Pinned:           e.IsPinned(),
+		ExpiresAt:        expiresAt,
+	}
+}
+
+// RevalidationStats returns cumulative revalidation outcomes keyed by strategy.
+func (m *Manager) RevalidationStats() map[string]RevalidationStats {
+	m.revalidationMu.Lock()
+	defer m.revalidationMu.Unlock()
+
+	stats := make(map[string]RevalidationStats, len(m.revalidation))
+	for strategy, tally := range m.revalidation {
+		stats[strategy] = *tally
+	}
+	return stats
+}
+
+// recordMarkedStale adds entries marked stale to the strategy's tally.
+func (m *Manager) recordMarkedStale(strategy string, count int) {
+	m.revalidationMu.Lock()
+	defer m.revalidationMu.Unlock()
+
+	tally := m.revalidationTally(strategy)
+	tally.MarkedStale += int64(count)
+	tally.LastRun = time.Now()
+}
+
+// recordRerender adds an eager re-render outcome to the strategy's tally.
+func (m *Manager) recordRerender(strategy string, succeeded bool) {
+	m.revalidationMu.Lock()
+	defer m.revalidationMu.Unlock()
+
+	tally := m.revalidationTally(strategy)
+	if succeeded {
+		tally.Rerendered++
+	} else {
+		tally.Failed++
+	}
+}
+
+// revalidationTally returns the strategy's tally, creating it if needed.
+// Callers must hold m.revalidationMu.
+func (m *Manager) revalidationTally(strategy string) *RevalidationStats {
+	if m.revalidation == nil {
+		m.revalidation = make(map[string]*RevalidationStats)
+	}
+	tally, ok := m.revalidation[strategy]
+	if !ok {
+		tally = &RevalidationStats{}
+		m.revalidation[strategy] = tally
+	}
+	return tally
+}
@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressionRatioWarning(t *testing.T) {
+	var logs bytes.Buffer
+	m, err := NewManager(t.TempDir(), slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	noise := make([]byte, 8*1024)
+	rand.Read(noise)
+	if err := m.SetSync("/noise:en", noise, "static", "/noise"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	text := []byte(strings.Repeat("<p>highly repetitive page</p>", 300))
+	if err := m.SetSync("/text:en", text, "static", "/text"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	infos := m.List()
+	if len(infos) != 2 {
+		t.Fatalf("entries = %d, want 2", len(infos))
+	}
+	noiseInfo, textInfo := infos[0], infos[1]
+	if noiseInfo.CompressionRatio < defaultCompressionWarnRatio {
+		t.Errorf("random content ratio = %.2f, want at least %.2f", noiseInfo.CompressionRatio, defaultCompressionWarnRatio)
+	}
+	if textInfo.CompressionRatio <= 0 || textInfo.CompressionRatio > 0.1 {
+		t.Errorf("repetitive content ratio = %.2f, want a small positive ratio", textInfo.CompressionRatio)
+	}
+
+	if got := strings.Count(logs.String(), "cached page compresses poorly"); got != 1 {
+		t.Errorf("poor compression warnings = %d, want 1 in:\n%s", got, logs.String())
+	}
+	if !strings.Contains(logs.String(), "key=/noise:en") {
+		t.Errorf("warning does not name the poorly compressing page:\n%s", logs.String())
+	}
+}
+
+func TestRevalidationStats(t *testing.T) {
+	m := newTestManager(t)
+	for _, canonical := range []string{"/about", "/broken"} {
+		if err := m.SetSync(canonical+":en", []byte("<p>page</p>"), "static", canonical); err != nil {
+			t.Fatalf("SetSync: %v", err)
+		}
+	}
+	if err := m.SetSync("/news:en", []byte("<p>news</p>"), "incremental", "/news"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	m.SetRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("<p>page</p>"))
+	}))
+
+	if marked := m.MarkStale("static", false); marked != 2 {
+		t.Fatalf("marked = %d, want 2", marked)
+	}
+	var stale []keyedEntry
+	for _, key := range []string{"/about:en", "/broken:en"} {
+		entry, _ := m.Get(key)
+		stale = append(stale, keyedEntry{key: key, entry: entry})
+	}
+	m.eagerRevalidate(stale, 1)
+
+	stats := m.RevalidationStats()
+	static := stats["static"]
+	if static.MarkedStale != 2 || static.Rerendered != 1 || static.Failed != 1 {
+		t.Errorf("static tally = %+v, want 2 marked, 1 re-rendered, 1 failed", static)
+	}
+	if static.LastRun.IsZero() {
+		t.Error("static tally has no last run time")
+	}
+	if _, ok := stats["incremental"]; ok {
+		t.Errorf("incremental tally = %+v, want none", stats["incremental"])
+	}
+}
+
+func TestExpiringWithin(t *testing.T) {
+	m := newTestManager(t)
+	m.SetTTLJitter(0)
+	seed(t, m, "incremental", "soon:en", "sooner:en", "expired:en", "new:en", "pinned:en")
+	seed(t, m, "static", "static:en")
+	age(t, m, "soon:en", 22*time.Hour)
+	age(t, m, "sooner:en", 23*time.Hour)
+	age(t, m, "expired:en", 25*time.Hour)
+	age(t, m, "pinned:en", 23*time.Hour)
+	age(t, m, "static:en", 23*time.Hour)
+	m.Pin("pinned:en")
+
+	var keys []string
+	for _, info := range m.ExpiringWithin(3 * time.Hour) {
+		keys = append(keys, info.Key)
+	}
+	if want := []string{"sooner:en", "soon:en"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("ExpiringWithin = %v, want %v", keys, want)
+	}
+}
+
+func TestTTLJitter(t *testing.T) {
+	keys := []string{"a:en", "b:en", "c:en", "d:en"}
+
+	// Offsets differ per key, stay within the jitter window and never change for a key
+	offsets := make(map[time.Duration]bool)
+	for _, key := range keys {
+		offset := ttlJitter(key, 0.1)
+		if offset < -incrementalTTL/10 || offset > incrementalTTL/10 {
+			t.Errorf("%s: offset %v outside ±10%% of the TTL", key, offset)
+		}
+		if again := ttlJitter(key, 0.1); again != offset {
+			t.Errorf("%s: offset changed from %v to %v", key, offset, again)
+		}
+		offsets[offset] = true
+	}
+	if len(offsets) < 2 {
+		t.Error("every key got the same offset")
+	}
+
+	// Entries rendered together expire at different times, unless jitter is disabled
+	tests := []struct {
+		jitter   float64
+		distinct bool
+	}{
+		{0.1, true},
+		{0, false},
+	}
+	for _, tt := range tests {
+		m := newTestManager(t)
+		m.SetTTLJitter(tt.jitter)
+		seed(t, m, "incremental", keys...)
+
+		ttls := make(map[time.Duration]bool)
+		for _, info := range m.List() {
+			ttls[info.ExpiresAt.Sub(info.RenderedAt)] = true
+		}
+		if got := len(ttls) > 1; got != tt.distinct {
+			t.Errorf("jitter %v: distinct TTLs = %v, want %v", tt.jitter, ttls, tt.distinct)
+		}
+		if !tt.distinct && !ttls[incrementalTTL] {
+			t.Errorf("jitter %v: TTLs = %v, want %v", tt.jitter, ttls, incrementalTTL)
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	m := newTestManager(t)
+	seed(t, m, "static", "/about:en")
+	seed(t, m, "incremental", "/news:en")
+	age(t, m, "/news:en", 90*time.Second)
+	news, _ := m.Get("/news:en")
+	news.MarkStale()
+
+	var out bytes.Buffer
+	if err := m.Dump(&out); err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Dump wrote %d lines, want header, 2 entries and totals:\n%s", len(lines), out.String())
+	}
+	if fields := strings.Fields(lines[0]); !reflect.DeepEqual(fields, []string{"KEY", "STRATEGY", "GEN", "AGE", "STALE", "SIZE", "UNCOMPRESSED", "ETAG"}) {
+		t.Errorf("header = %q", lines[0])
+	}
+
+	// Columns line up under the header
+	column := strings.Index(lines[0], "STRATEGY")
+	for _, line := range lines[1:3] {
+		if line[column-2:column] != "  " || line[column] == ' ' {
+			t.Errorf("row not aligned with the header: %q", line)
+		}
+	}
+
+	rows := make(map[string][]string)
+	for _, line := range lines[1:3] {
+		fields := strings.Fields(line)
+		rows[fields[0]] = fields
+	}
+	if news := rows["/news:en"]; len(news) != 8 || news[1] != "incremental" || news[3] != "1m30s" || news[4] != "true" {
+		t.Errorf("news row = %q", news)
+	}
+	if about := rows["/about:en"]; len(about) != 8 || about[1] != "static" || about[4] != "false" {
+		t.Errorf("about row = %q", about)
+	}
+	if !strings.HasPrefix(lines[3], "TOTAL") || !strings.Contains(lines[3], "2 entries") || !strings.Contains(lines[3], "1 stale") {
+		t.Errorf("totals = %q", lines[3])
+	}
+}
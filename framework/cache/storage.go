@@ -7,26 +7,42 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/andybalholm/brotli"
 )
 
 // Storage handles file I/O operations for cache.
 type Storage struct {
-	baseDir string
-	mu      sync.RWMutex // Protects file operations
+	baseDir      string
+	languageDirs bool                     // Store files in per-language subdirectories
+	retry        WriteRetryConfig         // Retries of writes failing with transient errors
+	writer       fileWriter               // Performs the actual file writes
+	metaIndex    map[string]EntryMeta     // Consolidated metadata when enabled; nil uses sidecars
+	metaAppends  int                      // Lines appended to the metadata index since it was compacted
+	metaVersion  uint64                   // Incremented on every metadata index change
+	writes       uint64                   // Incremented on every content write
+	pending      map[string]*pendingWrite // In-progress writes by cache key, see trackWrite
+	mu           sync.RWMutex             // Protects file operations
 }
 
 // NewStorage creates a new storage instance.
 func NewStorage(baseDir string) (*Storage, error) {
 	// Ensure cache directory exists
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		return nil, newError("create cache directory", "", ErrStorage, err)
 	}
 
+	return newStorage(baseDir), nil
+}
+
+// newStorage returns a storage rooted at baseDir without touching the filesystem.
+func newStorage(baseDir string) *Storage {
 	return &Storage{
 		baseDir: baseDir,
-	}, nil
+		retry:   DefaultWriteRetryConfig(),
+		writer:  os.WriteFile,
+	}
 }
 
 // Write stores cache entry to disk in both formats.
@@ -34,21 +50,36 @@ func (s *Storage) Write(cacheKey string, compressedContent, uncompressedContent
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	fileName := getCacheFileName(cacheKey)
-
-	// Write brotli-compressed version
-	brPath := filepath.Join(s.baseDir, fileName+".br")
-	if err := os.WriteFile(brPath, compressedContent, 0644); err != nil {
-		return fmt.Errorf("failed to write brotli cache file: %w", err)
+	// Ensure the (possibly per-language) directory exists
+	brPath := s.pathFor(cacheKey, ".br")
+	if err := os.MkdirAll(filepath.Dir(brPath), 0755); err != nil {
+		return newError("create cache directory", cacheKey, ErrStorage, err)
 	}
 
-	// Write uncompressed version
-	htmlPath := filepath.Join(s.baseDir, fileName+".html")
-	if err := os.WriteFile(htmlPath, uncompressedContent, 0644); err != nil {
-		return fmt.Errorf("failed to write HTML cache file: %w", err)
+	htmlPath := s.pathFor(cacheKey, ".html")
+	done := s.trackWrite(cacheKey)
+	defer done(brPath, htmlPath)
+
+	for {
+		s.writes++
+		version := s.writes
+
+		// Write brotli-compressed version
+		if err := s.writeFile(brPath, compressedContent, 0644); err != nil {
+			return newError("write brotli cache file", cacheKey, ErrStorage, err)
+		}
+
+		// Write uncompressed version
+		if err := s.writeFile(htmlPath, uncompressedContent, 0644); err != nil {
+			return newError("write HTML cache file", cacheKey, ErrStorage, err)
+		}
+
+		// Another write during a retry backoff may have replaced one of the
+		// files; write both again so they hold the same content
+		if s.writes == version {
+			return nil
+		}
 	}
-
-	return nil
 }
 
 // ReadBrotli reads brotli-compressed content from disk.
@@ -56,12 +87,11 @@ func (s *Storage) ReadBrotli(cacheKey string) ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fileName := getCacheFileName(cacheKey)
-	brPath := filepath.Join(s.baseDir, fileName+".br")
+	brPath := s.pathFor(cacheKey, ".br")
 
 	content, err := os.ReadFile(brPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read brotli cache file: %w", err)
+		return nil, newFileError("read brotli cache file", cacheKey, err)
 	}
 
 	return content, nil
@@ -72,24 +102,35 @@ func (s *Storage) ReadHTML(cacheKey string) ([]byte, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fileName := getCacheFileName(cacheKey)
-	htmlPath := filepath.Join(s.baseDir, fileName+".html")
+	htmlPath := s.pathFor(cacheKey, ".html")
 
 	content, err := os.ReadFile(htmlPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read HTML cache file: %w", err)
+		return nil, newFileError("read HTML cache file", cacheKey, err)
 	}
 
 	return content, nil
 }
 
+// ModTime returns when the cached content for the given key was last written.
+func (s *Storage) ModTime(cacheKey string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, err := os.Stat(s.pathFor(cacheKey, ".br"))
+	if err != nil {
+		return time.Time{}, newFileError("stat brotli cache file", cacheKey, err)
+	}
+
+	return info.ModTime(), nil
+}
+
 // Exists checks if cache files exist for the given key.
 func (s *Storage) Exists(cacheKey string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	fileName := getCacheFileName(cacheKey)
-	brPath := filepath.Join(s.baseDir, fileName+".br")
+	brPath := s.pathFor(cacheKey, ".br")
 
 	_, err := os.Stat(brPath)
 	return err == nil
@@ -100,18 +141,50 @@ func (s *Storage) Delete(cacheKey string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	fileName := getCacheFileName(cacheKey)
-
-	// Delete both files, ignore errors if files don't exist
-	brPath := filepath.Join(s.baseDir, fileName+".br")
-	htmlPath := filepath.Join(s.baseDir, fileName+".html")
+	// Delete content and metadata files, ignore errors if files don't exist
+	brPath := s.pathFor(cacheKey, ".br")
+	htmlPath := s.pathFor(cacheKey, ".html")
+	metaPath := s.pathFor(cacheKey, metaExt)
 
 	_ = os.Remove(brPath)
 	_ = os.Remove(htmlPath)
+	_ = os.Remove(metaPath)
+
+	// Writes backing off right now must not recreate the files
+	if p := s.pending[cacheKey]; p != nil {
+		p.deletes++
+	}
+
+	if _, ok := s.metaIndex[cacheKey]; ok {
+		delete(s.metaIndex, cacheKey)
+		if err := s.appendMetaRecord(metaRecord{EntryMeta: EntryMeta{Key: cacheKey}, Deleted: true}); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// SetLanguageDirectories enables storing each language's files in its own
+// subdirectory (e.g. "tr/about_tr.br"), so a language can be shipped or purged as a unit.
+func (s *Storage) SetLanguageDirectories(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.languageDirs = enabled
+}
+
+// pathFor returns the file path for a cache key with the given extension.
+// Callers must hold s.mu.
+func (s *Storage) pathFor(cacheKey, ext string) string {
+	fileName := getCacheFileName(cacheKey) + ext
+	if s.languageDirs {
+		if lang := keyLanguage(cacheKey); lang != "" {
+			return filepath.Join(s.baseDir, lang, fileName)
+		}
+	}
+	return filepath.Join(s.baseDir, fileName)
+}
+
 // CompressBrotli compresses content using brotli.
 func CompressBrotli(content []byte) ([]byte, error) {
 	var buf bytes.Buffer
@@ -135,7 +208,7 @@ func DecompressBrotli(compressed []byte) ([]byte, error) {
 
 	var buf bytes.Buffer
 	if _, err := buf.ReadFrom(reader); err != nil {
-		return nil, fmt.Errorf("failed to decompress content: %w", err)
+		return nil, newError("decompress content", "", ErrCorrupt, err)
 	}
 
 	return buf.Bytes(), nil
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLanguageDirectories(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetLanguageDirectories(true)
+
+	pages := map[string]string{
+		"/about:en": "<p>about us</p>",
+		"/about:tr": "<p>hakkımızda</p>",
+	}
+	for key, body := range pages {
+		if err := m.SetSync(key, []byte(body), "static", "/about"); err != nil {
+			t.Fatalf("SetSync %q: %v", key, err)
+		}
+	}
+
+	for _, lang := range []string{"en", "tr"} {
+		files, err := os.ReadDir(filepath.Join(dir, lang))
+		if err != nil {
+			t.Fatalf("read %s directory: %v", lang, err)
+		}
+		if len(files) == 0 {
+			t.Errorf("no files in the %s directory", lang)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, getCacheFileName("/about:en")+".br")); !os.IsNotExist(err) {
+		t.Errorf("flat file exists beside the language directories: %v", err)
+	}
+
+	// A restart with the same layout reads the pages back
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	restarted.SetLanguageDirectories(true)
+	for key, body := range pages {
+		if got := content(t, restarted, key); got != body {
+			t.Errorf("%s after restart = %q, want %q", key, got, body)
+		}
+	}
+}
+
+func TestMigrateToLanguageDirectories(t *testing.T) {
+	dir := t.TempDir()
+	flat, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	pages := map[string]string{
+		"/about:en": "<p>about us</p>",
+		"/about:tr": "<p>hakkımızda</p>",
+	}
+	for key, body := range pages {
+		if err := flat.SetSync(key, []byte(body), "static", "/about"); err != nil {
+			t.Fatalf("SetSync %q: %v", key, err)
+		}
+	}
+
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	m.SetLanguageDirectories(true)
+	moved, err := m.MigrateStorage()
+	if err != nil {
+		t.Fatalf("MigrateStorage: %v", err)
+	}
+
+	// Content, HTML and metadata sidecar per page
+	if want := len(pages) * 3; moved != want {
+		t.Errorf("moved = %d, want %d", moved, want)
+	}
+	for _, file := range cacheFiles(t, dir) {
+		if filepath.Dir(file) == dir {
+			t.Errorf("file left in the flat layout: %s", file)
+		}
+	}
+	for key, body := range pages {
+		if got := content(t, m, key); got != body {
+			t.Errorf("%s after migration = %q, want %q", key, got, body)
+		}
+	}
+
+	// Running it again is a no-op
+	if moved, err := m.MigrateStorage(); err != nil || moved != 0 {
+		t.Errorf("second MigrateStorage = %d, %v; want 0, nil", moved, err)
+	}
+}
+
+func TestSidecarlessETagStableAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := NewStorage(dir)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	html := []byte("<p>about</p>")
+	compressed, err := CompressBrotli(html)
+	if err != nil {
+		t.Fatalf("CompressBrotli: %v", err)
+	}
+	// Files left by an older version, without a metadata sidecar
+	if err := storage.Write("/about:en", compressed, html); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	var etags []string
+	for i := 0; i < 2; i++ {
+		m, err := NewManager(dir, discardLogger)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+		entry, ok := m.Get("/about:en")
+		if !ok {
+			t.Fatal("entry not loaded from disk")
+		}
+		etags = append(etags, entry.ETag)
+	}
+
+	if etags[0] != etags[1] {
+		t.Errorf("ETag changed across restarts: %q, then %q", etags[0], etags[1])
+	}
+}
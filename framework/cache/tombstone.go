@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tombstoneExt is the file extension used for persisted deletion markers.
+const tombstoneExt = ".gone"
+
+// Tombstone removes the cached page for a canonical path and language and
+// records a persistent deletion marker. Tombstoned pages are reported as gone
+// until ClearTombstone is called, and are skipped by Bootstrap and rebuild operations.
+// Markers are stored by path rather than cache key, so they survive changes to
+// the content version or key salt.
+func (m *Manager) Tombstone(canonical, lang string) error {
+	id := tombstoneID(canonical, lang)
+	cacheKey := GetCacheKey(canonical, lang, nil)
+
+	m.tombstones.Store(cacheKey, id)
+	if value, ok := m.entryMap().LoadAndDelete(cacheKey); ok {
+		m.untrackEntry(cacheKey)
+		m.emit(EventDelete, cacheKey, value.(*Entry).Strategy)
+	}
+
+	if !m.diskless {
+		if err := m.storage.Delete(cacheKey); err != nil {
+			return fmt.Errorf("failed to delete cache from disk: %w", err)
+		}
+
+		if err := m.storage.WriteTombstone(id); err != nil {
+			return fmt.Errorf("failed to persist tombstone: %w", err)
+		}
+	}
+
+	m.logger.Info("cache entry tombstoned",
+		slog.String("key", cacheKey),
+	)
+
+	return nil
+}
+
+// ClearTombstone removes the deletion marker for a page so it can be cached again.
+func (m *Manager) ClearTombstone(canonical, lang string) error {
+	id := tombstoneID(canonical, lang)
+	cacheKey := GetCacheKey(canonical, lang, nil)
+	m.tombstones.Delete(cacheKey)
+
+	if m.diskless {
+		return nil
+	}
+
+	if err := m.storage.DeleteTombstone(id); err != nil {
+		return fmt.Errorf("failed to remove tombstone: %w", err)
+	}
+
+	m.logger.Info("cache tombstone cleared",
+		slog.String("key", cacheKey),
+	)
+
+	return nil
+}
+
+// IsTombstoned reports whether the page cached under the key has been marked as gone.
+func (m *Manager) IsTombstoned(cacheKey string) bool {
+	_, ok := m.tombstones.Load(cacheKey)
+	return ok
+}
+
+// loadTombstones restores persisted deletion markers into memory, keyed by the
+// cache keys the current key options derive for them.
+func (m *Manager) loadTombstones() error {
+	ids, err := m.storage.ReadTombstones()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		canonical, lang := splitTombstoneID(id)
+		m.tombstones.Store(GetCacheKey(canonical, lang, nil), id)
+	}
+
+	if len(ids) > 0 {
+		m.logger.Debug("loaded cache tombstones",
+			slog.Int("count", len(ids)),
+		)
+	}
+
+	return nil
+}
+
+// tombstoneID identifies a tombstoned page as "canonical:lang", independent of
+// the content version and salt that cache keys are derived with.
+func tombstoneID(canonical, lang string) string {
+	return canonical + ":" + normalizeLanguage(lang, false)
+}
+
+// splitTombstoneID returns the canonical path and language of a tombstone ID.
+func splitTombstoneID(id string) (canonical, lang string) {
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[:i], id[i+1:]
+	}
+	return id, ""
+}
+
+// WriteTombstone persists a deletion marker for the given tombstone ID.
+// The marker file contains the ID so it can be restored on startup.
+func (s *Storage) WriteTombstone(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goneFile := s.pathFor(id, tombstoneExt)
+	if err := os.MkdirAll(filepath.Dir(goneFile), 0755); err != nil {
+		return newError("create cache directory", id, ErrStorage, err)
+	}
+
+	if err := s.writeFile(goneFile, []byte(id), 0644); err != nil {
+		return newError("write tombstone file", id, ErrStorage, err)
+	}
+
+	return nil
+}
+
+// DeleteTombstone removes the deletion marker for the given tombstone ID.
+func (s *Storage) DeleteTombstone(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goneFile := s.pathFor(id, tombstoneExt)
+	if err := os.Remove(goneFile); err != nil && !os.IsNotExist(err) {
+		return newError("remove tombstone file", id, ErrStorage, err)
+	}
+
+	return nil
+}
+
+// ReadTombstones returns the IDs of all persisted deletion markers.
+func (s *Storage) ReadTombstones() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Walk subdirectories too, since tombstones may live in per-language directories
+	var keys []string
+	err := filepath.WalkDir(s.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), tombstoneExt) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return newFileError("read tombstone file", "", err)
+		}
+
+		keys = append(keys, string(data))
+		return nil
+	})
+	if err != nil {
+		return nil, newFileError("read cache directory", "", err)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// setKeyOptions applies key options for the duration of the test.
+func setKeyOptions(t *testing.T, opts KeyOptions) {
+	t.Helper()
+	previous := GetKeyOptions()
+	SetKeyOptions(opts)
+	t.Cleanup(func() { SetKeyOptions(previous) })
+}
+
+func TestTombstoneSurvivesKeyOptionChanges(t *testing.T) {
+	dir := t.TempDir()
+	setKeyOptions(t, KeyOptions{Version: "v1", Salt: "first"})
+
+	m, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	key := GetCacheKey("/old", "en", nil)
+	if err := m.SetSync(key, []byte("<p>old</p>"), "static", "/old"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	if err := m.Tombstone("/old", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+
+	// A deploy bumps the version and rotates the salt
+	setKeyOptions(t, KeyOptions{Version: "v2", Salt: "second"})
+	restarted, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	newKey := GetCacheKey("/old", "en", nil)
+	if newKey == key {
+		t.Fatal("keys did not change with the key options")
+	}
+	if !restarted.IsTombstoned(newKey) {
+		t.Error("tombstone not matched after the key options changed")
+	}
+	if restarted.IsTombstoned(GetCacheKey("/old", "tr", nil)) {
+		t.Error("tombstone matched another language")
+	}
+
+	if err := restarted.ClearTombstone("/old", "en"); err != nil {
+		t.Fatalf("ClearTombstone: %v", err)
+	}
+	cleared, err := NewManager(dir, discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if cleared.IsTombstoned(newKey) {
+		t.Error("cleared tombstone restored on startup")
+	}
+}
+
+func TestTombstoneSkippedByRebuild(t *testing.T) {
+	m := newTestManager(t)
+	var renders atomic.Int32
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		w.Write([]byte("<p>page</p>"))
+	})
+	contact := RouteConfig{Canonical: "/contact", Paths: map[string]string{"en": "/contact"}, Strategy: "static"}
+	config := testRebuildConfig(t, router, []string{"en"}, aboutRoute, contact)
+
+	if err := m.Tombstone("/about", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+	if _, err := m.RebuildAll(context.Background(), config); err != nil {
+		t.Fatalf("RebuildAll: %v", err)
+	}
+
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1 for the live page only", got)
+	}
+	if _, ok := m.Get("/about:en"); ok {
+		t.Error("tombstoned page was re-warmed")
+	}
+	if _, ok := m.Get("/contact:en"); !ok {
+		t.Error("live page not rebuilt")
+	}
+}
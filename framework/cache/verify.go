@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
+)
+
+// VerifyMismatch describes a cached entry whose live render differs from the stored content.
+type VerifyMismatch struct {
+	Key          string
+	RequestPath  string
+	CachedSize   int
+	RenderedSize int
+	Err          error // Set when the page failed to render or the cache failed to decompress
+}
+
+// VerifyAgainstRouter re-renders a random sample of cached pages through the
+// router and compares the output with the stored content. Mismatches point at
+// nondeterministic templates (timestamps, random IDs) that make caching unsafe.
+// A fraction of 1 checks every entry; redirects and entries without a request path are skipped.
+func (m *Manager) VerifyAgainstRouter(ctx context.Context, router http.Handler, sampleFraction float64) ([]VerifyMismatch, error) {
+	if sampleFraction <= 0 || sampleFraction > 1 {
+		return nil, fmt.Errorf("sample fraction must be in (0, 1]: %v", sampleFraction)
+	}
+
+	var candidates []EntryInfo
+	for _, info := range m.List() {
+		value, ok := m.entryMap().Load(info.Key)
+		if !ok || info.RequestPath == "" {
+			continue
+		}
+		if status, _ := value.(*Entry).Redirect(); status != 0 {
+			continue
+		}
+		candidates = append(candidates, info)
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sampleSize := int(math.Ceil(float64(len(candidates)) * sampleFraction))
+
+	var mismatches []VerifyMismatch
+	for i := 0; i < sampleSize; i++ {
+		if err := ctx.Err(); err != nil {
+			return mismatches, err
+		}
+
+		info := candidates[i]
+		mismatch := VerifyMismatch{Key: info.Key, RequestPath: info.RequestPath}
+
+		value, ok := m.entryMap().Load(info.Key)
+		if !ok {
+			continue // Deleted since sampling
+		}
+
+		cached, err := GetDecompressedContent(value.(*Entry))
+		if err != nil {
+			mismatch.Err = err
+			mismatches = append(mismatches, mismatch)
+			continue
+		}
+		mismatch.CachedSize = len(cached)
+
+		rendered, _, err := m.makeCacheRequest(ctx, router, info.RequestPath)
+		if err != nil {
+			mismatch.Err = err
+			mismatches = append(mismatches, mismatch)
+			continue
+		}
+		mismatch.RenderedSize = len(rendered)
+
+		if !bytes.Equal(cached, rendered) {
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	m.logger.Info("cache verification completed",
+		slog.Int("checked", sampleSize),
+		slog.Int("mismatches", len(mismatches)),
+	)
+
+	for _, mismatch := range mismatches {
+		attrs := []any{
+			slog.String("key", mismatch.Key),
+			slog.String("request_path", mismatch.RequestPath),
+			slog.Int("cached_size", mismatch.CachedSize),
+			slog.Int("rendered_size", mismatch.RenderedSize),
+		}
+		if mismatch.Err != nil {
+			attrs = append(attrs, slog.String("error", mismatch.Err.Error()))
+		}
+		m.logger.Warn("cached page differs from live render", attrs...)
+	}
+
+	return mismatches, nil
+}
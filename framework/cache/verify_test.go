@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVerifyAgainstRouter(t *testing.T) {
+	var renders atomic.Int32
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := renders.Add(1)
+		switch r.URL.Path {
+		case "/clock":
+			fmt.Fprintf(w, "<p>rendered #%d</p>", n) // Differs on every render
+		case "/broken":
+			if n > 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			fmt.Fprint(w, "<p>broken</p>")
+		default:
+			fmt.Fprintf(w, "<p>%s</p>", r.URL.Path)
+		}
+	})
+
+	m := newTestManager(t)
+	m.SetMemoryOnlyStrategies("static") // SetRedirect writes in the background
+	for _, path := range []string{"/clock", "/broken", "/about"} {
+		if err := m.WarmOne(context.Background(), router, path, "en", path, "static"); err != nil {
+			t.Fatalf("WarmOne %s: %v", path, err)
+		}
+	}
+	if err := m.SetRedirect("/old:en", http.StatusMovedPermanently, "/about", "static", "/old"); err != nil {
+		t.Fatalf("SetRedirect: %v", err)
+	}
+
+	mismatches, err := m.VerifyAgainstRouter(context.Background(), router, 1)
+	if err != nil {
+		t.Fatalf("VerifyAgainstRouter: %v", err)
+	}
+
+	got := make(map[string]VerifyMismatch)
+	for _, mismatch := range mismatches {
+		got[mismatch.Key] = mismatch
+	}
+	if len(got) != 2 {
+		t.Errorf("mismatches = %+v, want /clock:en and /broken:en", mismatches)
+	}
+	if clock, ok := got["/clock:en"]; !ok || clock.Err != nil {
+		t.Errorf("nondeterministic page not reported as a content mismatch: %+v", clock)
+	}
+	if broken, ok := got["/broken:en"]; !ok || broken.Err == nil {
+		t.Errorf("failing render not reported with its error: %+v", broken)
+	}
+
+	if _, err := m.VerifyAgainstRouter(context.Background(), router, 0); err == nil {
+		t.Error("zero sample fraction accepted")
+	}
+}
@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// StaleWarmer periodically re-renders stale entries independent of request traffic,
+// so low-traffic pages do not stay stale until their next visitor.
+type StaleWarmer struct {
+	manager *Manager
+	logger  *slog.Logger
+	ticker  *time.Ticker
+	done    chan bool
+	running atomic.Bool
+}
+
+// NewStaleWarmer creates a new stale warmer instance.
+func NewStaleWarmer(manager *Manager, logger *slog.Logger) *StaleWarmer {
+	return &StaleWarmer{
+		manager: manager,
+		logger:  logger,
+		done:    make(chan bool),
+	}
+}
+
+// Start begins scanning for stale entries every interval,
+// re-rendering at most concurrency pages at a time.
+func (sw *StaleWarmer) Start(interval time.Duration, concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sw.logger.Info("starting stale cache warmer",
+		slog.Duration("interval", interval),
+		slog.Int("concurrency", concurrency),
+	)
+
+	sw.ticker = time.NewTicker(interval)
+
+	go func() {
+		for {
+			select {
+			case <-sw.ticker.C:
+				sw.warm(concurrency)
+			case <-sw.done:
+				sw.logger.Info("stale cache warmer stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the stale warmer.
+func (sw *StaleWarmer) Stop() {
+	if sw.ticker != nil {
+		sw.ticker.Stop()
+	}
+	close(sw.done)
+}
+
+// warm re-renders pages queued for priority warming, then all stale entries,
+// skipping the tick if a previous pass is still running.
+func (sw *StaleWarmer) warm(concurrency int) {
+	if !sw.running.CompareAndSwap(false, true) {
+		sw.logger.Debug("stale cache warm skipped - previous pass still running")
+		return
+	}
+	defer sw.running.Store(false)
+
+	sw.manager.warmPriority()
+
+	entries := sw.manager.staleEntries()
+	if len(entries) == 0 {
+		return
+	}
+
+	sw.manager.eagerRevalidate(entries, concurrency)
+}
+
+// staleEntries returns entries due for revalidation that can be re-rendered.
+// Entries due because their TTL ran out or a predicate fired are marked stale,
+// so the cache middleware re-renders them instead of answering from the cache.
+func (m *Manager) staleEntries() []keyedEntry {
+	var entries []keyedEntry
+
+	m.entryMap().Range(func(key, value interface{}) bool {
+		entry := value.(*Entry)
+		if !entry.ShouldRevalidate() {
+			return true
+		}
+
+		entry.mu.RLock()
+		requestPath := entry.RequestPath
+		entry.mu.RUnlock()
+
+		if requestPath != "" {
+			if !entry.IsStale() {
+				entry.MarkStale()
+				m.emit(EventMarkStale, key.(string), entry.Strategy)
+			}
+			entries = append(entries, keyedEntry{key: key.(string), entry: entry})
+		}
+		return true
+	})
+
+	return entries
+}
+
+const (
+	// maxPriorityAttempts is how often a queued page is rendered before it is given up on.
+	maxPriorityAttempts = 5
+	// priorityRetryDelay is the backoff after a page's first failed priority render,
+	// doubled after every further failure.
+	priorityRetryDelay = 30 * time.Second
+)
+
+// priorityWarm is a page queued for priority warming.
+type priorityWarm struct {
+	failure  WarmFailure
+	attempts int       // Failed priority renders so far
+	next     time.Time // Not rendered again before this time
+}
+
+// QueuePriorityWarm queues pages that failed to warm (e.g. during Bootstrap)
+// so the StaleWarmer renders them on its next pass, before stale entries.
+// Pages that fail again are retried with backoff, up to maxPriorityAttempts
+// renders, and tombstoned pages are dropped from the queue.
+func (m *Manager) QueuePriorityWarm(failures []WarmFailure) {
+	m.priorityMu.Lock()
+	defer m.priorityMu.Unlock()
+	for _, failure := range failures {
+		m.priority = append(m.priority, priorityWarm{failure: failure})
+	}
+}
+
+// warmPriority renders the queued pages that are due, re-queueing failures
+// with backoff until they run out of attempts.
+func (m *Manager) warmPriority() {
+	m.priorityMu.Lock()
+	queued := m.priority
+	m.priority = nil
+	m.priorityMu.Unlock()
+
+	if len(queued) == 0 {
+		return
+	}
+
+	m.mu.RLock()
+	router := m.router
+	m.mu.RUnlock()
+
+	if router == nil {
+		m.logger.Warn("priority warming skipped - router not set")
+		m.requeuePriority(queued)
+		return
+	}
+
+	now := time.Now()
+	var requeue []priorityWarm
+	rendered, failed := 0, 0
+	for _, page := range queued {
+		path := page.failure.Route.Paths[page.failure.Lang]
+		if path == "" {
+			continue
+		}
+
+		// Intentionally removed pages can never be warmed
+		cacheKey := GetCacheKey(page.failure.Route.Canonical, page.failure.Lang, nil)
+		if m.IsTombstoned(cacheKey) {
+			continue
+		}
+
+		if now.Before(page.next) {
+			requeue = append(requeue, page)
+			continue
+		}
+
+		rendered++
+		err := m.WarmOne(context.Background(), router, page.failure.Route.Canonical, page.failure.Lang, path, page.failure.Route.Strategy)
+		if err == nil {
+			continue
+		}
+
+		failed++
+		page.failure.Err = err
+		page.attempts++
+		if page.attempts >= maxPriorityAttempts {
+			m.logger.Error("priority warming gave up on page",
+				slog.String("key", cacheKey),
+				slog.Int("attempts", page.attempts),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		page.next = now.Add(priorityRetryDelay << (page.attempts - 1))
+		requeue = append(requeue, page)
+	}
+
+	if rendered > 0 {
+		m.logger.Info("priority cache warming completed",
+			slog.Int("pages", rendered),
+			slog.Int("failed", failed),
+		)
+	}
+
+	m.requeuePriority(requeue)
+}
+
+// requeuePriority puts pages back on the priority queue, keeping their attempts.
+func (m *Manager) requeuePriority(pages []priorityWarm) {
+	if len(pages) == 0 {
+		return
+	}
+	m.priorityMu.Lock()
+	defer m.priorityMu.Unlock()
+	m.priority = append(m.priority, pages...)
+}
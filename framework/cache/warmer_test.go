@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cachingRouter mimics the cache middleware in front of a page: fresh entries
+// are served from the cache, missing or stale ones are rendered and stored.
+func cachingRouter(m *Manager, key, strategy string, renders *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := m.Get(key); ok && !entry.IsStale() {
+			body, _ := GetDecompressedContent(entry)
+			w.Write(body)
+			return
+		}
+
+		n := renders.Add(1)
+		body := []byte(fmt.Sprintf("<p>render %d</p>", n))
+		if err := m.SetSync(key, body, strategy, r.URL.Path); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(body)
+	})
+}
+
+// age moves an entry's render time into the past.
+func age(t *testing.T, m *Manager, key string, by time.Duration) {
+	t.Helper()
+	entry, ok := m.Get(key)
+	if !ok {
+		t.Fatalf("entry %q not found", key)
+	}
+	entry.mu.Lock()
+	entry.RenderedAt = entry.RenderedAt.Add(-by)
+	entry.mu.Unlock()
+}
+
+func TestStaleWarmerRerendersExpiredEntries(t *testing.T) {
+	m := newTestManager(t)
+	var renders atomic.Int32
+	m.SetRouter(cachingRouter(m, "/news:en", "incremental", &renders))
+	if err := m.SetSync("/news:en", []byte("<p>initial</p>"), "incremental", "/news"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	// Expired by TTL but never marked stale
+	age(t, m, "/news:en", 48*time.Hour)
+
+	warmer := NewStaleWarmer(m, discardLogger)
+	warmer.warm(1)
+
+	if got := renders.Load(); got != 1 {
+		t.Fatalf("renders = %d, want 1", got)
+	}
+	if got := content(t, m, "/news:en"); got != "<p>render 1</p>" {
+		t.Errorf("content = %q, want the re-rendered page", got)
+	}
+
+	// The refreshed entry is no longer picked up
+	warmer.warm(1)
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders after second pass = %d, want 1", got)
+	}
+}
+
+func TestStaleWarmerRerendersPredicateFlaggedEntries(t *testing.T) {
+	m := newTestManager(t)
+	var renders atomic.Int32
+	m.SetRouter(cachingRouter(m, "/prices:en", "data", &renders))
+
+	version := "1"
+	m.RegisterDataVersion("data", func(string) string { return version })
+	if err := m.SetSync("/prices:en", []byte("<p>initial</p>"), "data", "/prices"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+
+	version = "2"
+	NewStaleWarmer(m, discardLogger).warm(1)
+
+	if got := renders.Load(); got != 1 {
+		t.Fatalf("renders = %d, want 1", got)
+	}
+	entry, _ := m.Get("/prices:en")
+	if entry.ShouldRevalidate() {
+		t.Error("entry still due for revalidation after re-render")
+	}
+}
+
+// dueNow makes every page on the priority queue due for its next attempt.
+func dueNow(m *Manager) {
+	m.priorityMu.Lock()
+	defer m.priorityMu.Unlock()
+	for i := range m.priority {
+		m.priority[i].next = time.Time{}
+	}
+}
+
+func TestWarmPriorityGivesUp(t *testing.T) {
+	m := newTestManager(t)
+	var requests atomic.Int32
+	m.SetRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	m.QueuePriorityWarm([]WarmFailure{{Route: aboutRoute, Lang: "en"}})
+
+	for pass := 0; pass < maxPriorityAttempts+2; pass++ {
+		dueNow(m)
+		m.warmPriority()
+	}
+
+	if got := requests.Load(); got != maxPriorityAttempts {
+		t.Errorf("renders = %d, want %d", got, maxPriorityAttempts)
+	}
+	if len(m.priority) != 0 {
+		t.Errorf("queue = %+v, want the page given up on", m.priority)
+	}
+}
+
+func TestWarmPriorityDropsTombstonedPages(t *testing.T) {
+	m := newTestManager(t)
+	var requests atomic.Int32
+	m.SetRouter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("<p>about</p>"))
+	}))
+	m.QueuePriorityWarm([]WarmFailure{{Route: aboutRoute, Lang: "en"}})
+	if err := m.Tombstone("/about", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+
+	m.warmPriority()
+
+	if got := requests.Load(); got != 0 {
+		t.Errorf("renders = %d, want 0", got)
+	}
+	if len(m.priority) != 0 {
+		t.Errorf("queue = %+v, want the tombstoned page dropped", m.priority)
+	}
+}
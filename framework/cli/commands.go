@@ -20,6 +20,7 @@ type PrerenderCommandConfig struct {
 	Router       http.Handler
 	CacheManager *cache.Manager
 	Logger       *slog.Logger
+	Workers      int // Routes rendered in parallel (default: 10)
 }
 
 // NewPrerenderCommand creates a new prerender command.
@@ -31,16 +32,38 @@ func NewPrerenderCommand(config PrerenderCommandConfig) *Command {
 		Run: func() error {
 			config.Logger.Info("Starting cache pre-rendering...")
 
-			if err := config.CacheManager.Bootstrap(context.Background(), cache.RebuildConfig{
+			rebuildConfig := cache.RebuildConfig{
 				ConfigFS:   config.ConfigFS,
 				RoutesFile: config.RoutesFile,
 				Languages:  config.Languages,
 				Router:     config.Router,
 				Logger:     config.Logger,
-			}); err != nil {
+				Workers:    config.Workers,
+				OnProgress: func(progress cache.WarmupProgress) {
+					config.Logger.Debug("Pre-rendering progress",
+						slog.Int("done", progress.Done),
+						slog.Int("total", progress.Total),
+						slog.Int("percent", int(progress.Percent())),
+					)
+				},
+			}
+
+			failures, err := config.CacheManager.Bootstrap(context.Background(), rebuildConfig)
+			if err != nil {
 				return fmt.Errorf("pre-rendering failed: %w", err)
 			}
 
+			// Give pages that failed (e.g. a briefly unavailable dependency) another chance
+			if len(failures) > 0 {
+				remaining, err := config.CacheManager.RetryFailed(context.Background(), failures, rebuildConfig)
+				if err != nil {
+					return fmt.Errorf("pre-rendering retry failed: %w", err)
+				}
+				if len(remaining) > 0 {
+					return fmt.Errorf("pre-rendering failed for %d pages", len(remaining))
+				}
+			}
+
 			config.Logger.Info("Cache pre-rendering completed successfully")
 			return nil
 		},
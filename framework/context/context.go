@@ -10,13 +10,33 @@ type ContextKey string
 
 // Context keys used across the framework.
 const (
-	LanguageKey      ContextKey = "language"
-	CanonicalPathKey ContextKey = "canonicalPath"
-	PageTitleKey     ContextKey = "pageTitle"
-	StrategyKey      ContextKey = "cacheStrategy"
-	LayoutDataKey    ContextKey = "layoutData"
+	LanguageKey           ContextKey = "language"
+	CanonicalPathKey      ContextKey = "canonicalPath"
+	PageTitleKey          ContextKey = "pageTitle"
+	StrategyKey           ContextKey = "cacheStrategy"
+	LayoutDataKey         ContextKey = "layoutData"
+	StrategyResolutionKey ContextKey = "cacheStrategyResolution"
+	FormatKey             ContextKey = "responseFormat"
+	ThemeKey              ContextKey = "theme"
 )
 
+// StrategySource identifies where a cache strategy was resolved from.
+type StrategySource string
+
+// Cache strategy sources.
+const (
+	StrategySourceRoute   StrategySource = "route"   // Default from the route configuration
+	StrategySourceHandler StrategySource = "handler" // Overridden by the handler at runtime
+	StrategySourceHeader  StrategySource = "header"  // Overridden via the X-Cache-Strategy response header
+	StrategySourcePrefix  StrategySource = "prefix"  // Assigned by a path-prefix rule for routes outside the route table
+)
+
+// StrategyResolution is a cache strategy together with the place it came from.
+type StrategyResolution struct {
+	Strategy string
+	Source   StrategySource
+}
+
 // GetLanguage retrieves the language from context.
 func GetLanguage(ctx gocontext.Context) string {
 	if lang, ok := ctx.Value(LanguageKey).(string); ok {
@@ -43,6 +63,34 @@ func SetCanonicalPath(ctx gocontext.Context, canonical string) gocontext.Context
 	return gocontext.WithValue(ctx, CanonicalPathKey, canonical)
 }
 
+// GetFormat retrieves the negotiated response media type from context.
+// Returns "" when no format negotiation took place.
+func GetFormat(ctx gocontext.Context) string {
+	if format, ok := ctx.Value(FormatKey).(string); ok {
+		return format
+	}
+	return ""
+}
+
+// SetFormat creates a new context with the negotiated response media type set.
+func SetFormat(ctx gocontext.Context, format string) gocontext.Context {
+	return gocontext.WithValue(ctx, FormatKey, format)
+}
+
+// GetTheme retrieves the resolved theme preference from context.
+// Returns "" when no theme variants are configured.
+func GetTheme(ctx gocontext.Context) string {
+	if theme, ok := ctx.Value(ThemeKey).(string); ok {
+		return theme
+	}
+	return ""
+}
+
+// SetTheme creates a new context with the resolved theme preference set.
+func SetTheme(ctx gocontext.Context, theme string) gocontext.Context {
+	return gocontext.WithValue(ctx, ThemeKey, theme)
+}
+
 // GetPageTitle retrieves the page title from context.
 func GetPageTitle(ctx gocontext.Context) string {
 	if title, ok := ctx.Value(PageTitleKey).(string); ok {
@@ -57,7 +105,11 @@ func SetPageTitle(ctx gocontext.Context, title string) gocontext.Context {
 }
 
 // GetStrategy retrieves the cache strategy from context.
+// A strategy resolution, when present, takes precedence over SetStrategy.
 func GetStrategy(ctx gocontext.Context) string {
+	if resolution, ok := ctx.Value(StrategyResolutionKey).(*StrategyResolution); ok {
+		return resolution.Strategy
+	}
 	if strategy, ok := ctx.Value(StrategyKey).(string); ok {
 		return strategy
 	}
@@ -69,6 +121,41 @@ func SetStrategy(ctx gocontext.Context, strategy string) gocontext.Context {
 	return gocontext.WithValue(ctx, StrategyKey, strategy)
 }
 
+// SetStrategyResolution creates a new context with the cache strategy and its source set.
+// The resolution is shared with derived contexts so handlers can override it.
+func SetStrategyResolution(ctx gocontext.Context, strategy string, source StrategySource) gocontext.Context {
+	return gocontext.WithValue(ctx, StrategyResolutionKey, &StrategyResolution{
+		Strategy: strategy,
+		Source:   source,
+	})
+}
+
+// GetStrategyResolution retrieves the cache strategy and its source from context.
+func GetStrategyResolution(ctx gocontext.Context) (StrategyResolution, bool) {
+	if resolution, ok := ctx.Value(StrategyResolutionKey).(*StrategyResolution); ok {
+		return *resolution, true
+	}
+	return StrategyResolution{}, false
+}
+
+// OverrideStrategy replaces the resolved cache strategy for the current request,
+// recording the handler as its source. Returns false if no resolution is present.
+func OverrideStrategy(ctx gocontext.Context, strategy string) bool {
+	return OverrideStrategyFrom(ctx, strategy, StrategySourceHandler)
+}
+
+// OverrideStrategyFrom replaces the resolved cache strategy for the current request,
+// recording the given source. Returns false if no resolution is present.
+func OverrideStrategyFrom(ctx gocontext.Context, strategy string, source StrategySource) bool {
+	resolution, ok := ctx.Value(StrategyResolutionKey).(*StrategyResolution)
+	if !ok {
+		return false
+	}
+	resolution.Strategy = strategy
+	resolution.Source = source
+	return true
+}
+
 // GetLayoutData retrieves the layout data from context.
 func GetLayoutData(ctx gocontext.Context) interface{} {
 	return ctx.Value(LayoutDataKey)
@@ -78,3 +165,36 @@ func GetLayoutData(ctx gocontext.Context) interface{} {
 func SetLayoutData(ctx gocontext.Context, data interface{}) gocontext.Context {
 	return gocontext.WithValue(ctx, LayoutDataKey, data)
 }
+
+// internalRequestKey marks requests issued in-process by the cache warm path.
+// It is unexported so only MarkInternalRequest can set it; clients cannot forge it.
+type internalRequestKey struct{}
+
+// MarkInternalRequest creates a new context flagging the request as an in-process
+// warm-up render, which bypasses rate limiting, host redirects and cache lookups.
+func MarkInternalRequest(ctx gocontext.Context) gocontext.Context {
+	return gocontext.WithValue(ctx, internalRequestKey{}, true)
+}
+
+// IsInternalRequest reports whether the request was issued by the cache warm path.
+func IsInternalRequest(ctx gocontext.Context) bool {
+	internal, _ := ctx.Value(internalRequestKey{}).(bool)
+	return internal
+}
+
+// revalidationRequestKey marks in-process re-renders of stale cache entries.
+type revalidationRequestKey struct{}
+
+// MarkRevalidationRequest creates a new context flagging the request as an
+// in-process revalidation. Unlike warm-up requests, revalidations go through
+// the cache middleware so their result is stored, but they still bypass rate
+// limiting and host redirects.
+func MarkRevalidationRequest(ctx gocontext.Context) gocontext.Context {
+	return gocontext.WithValue(ctx, revalidationRequestKey{}, true)
+}
+
+// IsRevalidationRequest reports whether the request revalidates a cache entry.
+func IsRevalidationRequest(ctx gocontext.Context) bool {
+	revalidation, _ := ctx.Value(revalidationRequestKey{}).(bool)
+	return revalidation
+}
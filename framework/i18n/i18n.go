@@ -7,18 +7,23 @@ import (
 	"io/fs"
 	"path"
 	"strings"
+
+	"statigo/framework/utils"
 )
 
 // I18n manages translations for multiple languages.
 type I18n struct {
 	translations map[string]map[string]interface{}
+	lookup       map[string]map[string]interface{} // Flattened dotted key -> value, per language
 	defaultLang  string
+	version      string // Fingerprint of the loaded translation files
 }
 
 // New creates a new I18n instance by loading translations from the given filesystem.
 func New(translationsFS fs.FS, defaultLang string) (*I18n, error) {
 	i18n := &I18n{
 		translations: make(map[string]map[string]interface{}),
+		lookup:       make(map[string]map[string]interface{}),
 		defaultLang:  defaultLang,
 	}
 
@@ -42,43 +47,37 @@ func New(translationsFS fs.FS, defaultLang string) (*I18n, error) {
 			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
 		}
 
-		// Store raw nested translations
+		// Store raw nested translations along with a flat lookup table
 		i18n.translations[lang] = translations
+		i18n.lookup[lang] = flatten(translations)
 	}
 
+	version, err := utils.HashFS(translationsFS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fingerprint translations: %w", err)
+	}
+	i18n.version = version
+
 	return i18n, nil
 }
 
+// ContentVersion returns a fingerprint of the loaded translations.
+// It changes whenever a translation file is added, removed or edited.
+func (i *I18n) ContentVersion() string {
+	return i.version
+}
+
 // GetRaw retrieves raw structured data (arrays, objects) from translations using dot notation.
 // Example: GetRaw("en", "features.descriptions") returns []interface{}
 func (i *I18n) GetRaw(lang, key string) interface{} {
-	// Helper to navigate nested map using dot notation
-	getValue := func(data map[string]interface{}, path string) interface{} {
-		parts := strings.Split(path, ".")
-		var current interface{} = data
-
-		for _, part := range parts {
-			if currentMap, ok := current.(map[string]interface{}); ok {
-				current = currentMap[part]
-			} else {
-				return nil
-			}
-		}
-		return current
-	}
-
 	// Try requested language
-	if trans, ok := i.translations[lang]; ok {
-		if value := getValue(trans, key); value != nil {
-			return value
-		}
+	if value := i.lookup[lang][key]; value != nil {
+		return value
 	}
 
 	// Fallback to default language
-	if trans, ok := i.translations[i.defaultLang]; ok {
-		if value := getValue(trans, key); value != nil {
-			return value
-		}
+	if value := i.lookup[i.defaultLang][key]; value != nil {
+		return value
 	}
 
 	// Return nil if not found
@@ -108,3 +107,26 @@ func (i *I18n) GetSupportedLanguages() []string {
 func (i *I18n) DefaultLanguage() string {
 	return i.defaultLang
 }
+
+// flatten builds a lookup table of every dotted key path in a nested translation map,
+// so lookups don't walk the tree. Intermediate objects are kept under their own path.
+func flatten(data map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for key, value := range node {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flat[path] = value
+			if nested, ok := value.(map[string]interface{}); ok {
+				walk(path, nested)
+			}
+		}
+	}
+	walk("", data)
+
+	return flat
+}
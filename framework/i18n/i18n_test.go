@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testTranslations = fstest.MapFS{
+	"en.json": {Data: []byte(`{
+		"main": {"title": "Home", "nav": {"about": "About", "blog": "Blog"}},
+		"features": {"descriptions": ["Fast", "Cached"]},
+		"footer": "Made with Statigo"
+	}`)},
+	"tr.json": {Data: []byte(`{
+		"main": {"title": "Ana Sayfa", "nav": {"about": "Hakkımızda"}}
+	}`)},
+}
+
+func newTestI18n(tb testing.TB) *I18n {
+	tb.Helper()
+	i, err := New(testTranslations, "en")
+	if err != nil {
+		tb.Fatalf("New: %v", err)
+	}
+	return i
+}
+
+// walk resolves a dotted key by walking the nested translations, as lookups did
+// before the flat table.
+func walk(data map[string]interface{}, key string) interface{} {
+	var current interface{} = data
+	for _, part := range strings.Split(key, ".") {
+		node, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = node[part]
+	}
+	return current
+}
+
+func TestGetRawMatchesNestedLookup(t *testing.T) {
+	i := newTestI18n(t)
+	keys := []string{
+		"main.title", "main.nav", "main.nav.about", "main.nav.blog",
+		"features.descriptions", "footer", "footer.extra", "missing", "main.title.extra",
+	}
+
+	for _, lang := range []string{"en", "tr", "de"} {
+		for _, key := range keys {
+			want := walk(i.translations[lang], key)
+			if want == nil {
+				want = walk(i.translations["en"], key)
+			}
+			if got := i.GetRaw(lang, key); !reflect.DeepEqual(got, want) {
+				t.Errorf("GetRaw(%q, %q) = %v, want %v", lang, key, got, want)
+			}
+		}
+	}
+
+	if got := i.Get("tr", "main.nav.blog"); got != "Blog" {
+		t.Errorf("Get fell back to %q, want the default language", got)
+	}
+	if got := i.Get("tr", "missing.key"); got != "missing.key" {
+		t.Errorf("Get(missing) = %q, want the key", got)
+	}
+}
+
+func BenchmarkGetRaw(b *testing.B) {
+	i := newTestI18n(b)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		i.GetRaw("tr", "main.nav.blog")
+	}
+}
+
+func BenchmarkNestedLookup(b *testing.B) {
+	i := newTestI18n(b)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		if walk(i.translations["tr"], "main.nav.blog") == nil {
+			walk(i.translations["en"], "main.nav.blog")
+		}
+	}
+}
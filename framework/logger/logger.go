@@ -18,38 +18,90 @@ type contextKey string
 
 const requestIDKey contextKey = "request_id"
 
-// InitLogger initializes and returns a structured logger.
-func InitLogger(level string) *slog.Logger {
-	var logLevel slog.Level
-	switch strings.ToUpper(level) {
-	case "DEBUG":
-		logLevel = slog.LevelDebug
-	case "INFO":
-		logLevel = slog.LevelInfo
-	case "WARN":
-		logLevel = slog.LevelWarn
-	case "ERROR":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
+// Attribute keys shared by all framework components.
+const (
+	KeyService   = "service"
+	KeyVersion   = "version"
+	KeyRequestID = "request_id"
+)
+
+// Config contains configuration for building a logger.
+type Config struct {
+	Level   string    // DEBUG, INFO, WARN or ERROR (default: INFO)
+	Format  string    // JSON, TEXT or BRACKET (default: BRACKET)
+	Output  io.Writer // Destination for log records (default: os.Stdout)
+	Service string    // Added to every record as "service" when set
+	Version string    // Added to every record as "version" when set
+}
+
+// DefaultConfig returns default configuration.
+func DefaultConfig() Config {
+	return Config{
+		Level:  "INFO",
+		Format: "BRACKET",
+		Output: os.Stdout,
 	}
+}
 
-	var handler slog.Handler
-	format := os.Getenv("LOG_FORMAT")
+// New builds a structured logger from the configuration. JSON suits production
+// log pipelines; TEXT and BRACKET are meant for development.
+func New(config Config) *slog.Logger {
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
 
 	opts := &slog.HandlerOptions{
-		Level: logLevel,
+		Level: parseLevel(config.Level),
+	}
+
+	var handler slog.Handler
+	switch strings.ToUpper(config.Format) {
+	case "JSON":
+		handler = slog.NewJSONHandler(output, opts)
+	case "TEXT":
+		handler = slog.NewTextHandler(output, opts)
+	default:
+		handler = NewBracketHandler(output, opts)
 	}
 
-	if strings.ToUpper(format) == "JSON" {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
-	} else {
-		handler = NewBracketHandler(os.Stdout, opts)
+	var attrs []slog.Attr
+	if config.Service != "" {
+		attrs = append(attrs, slog.String(KeyService, config.Service))
+	}
+	if config.Version != "" {
+		attrs = append(attrs, slog.String(KeyVersion, config.Version))
+	}
+	if len(attrs) > 0 {
+		handler = handler.WithAttrs(attrs)
 	}
 
 	return slog.New(handler)
 }
 
+// InitLogger initializes and returns a structured logger.
+// The format is read from the LOG_FORMAT environment variable.
+func InitLogger(level string) *slog.Logger {
+	config := DefaultConfig()
+	config.Level = level
+	config.Format = os.Getenv("LOG_FORMAT")
+	return New(config)
+}
+
+// parseLevel converts a level name to a slog level, defaulting to INFO.
+func parseLevel(level string) slog.Level {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return slog.LevelDebug
+	case "WARN":
+		return slog.LevelWarn
+	case "ERROR":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
 // WithRequestID adds a request ID to the context.
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, requestIDKey, requestID)
@@ -90,6 +142,7 @@ type BracketHandler struct {
 	keyColor     string
 	timeColor    string
 	messageColor string
+	attrs        []slog.Attr // Attributes added via WithAttrs, written before record attributes
 }
 
 // NewBracketHandler creates a new BracketHandler.
@@ -178,7 +231,7 @@ func (h *BracketHandler) Handle(_ context.Context, r slog.Record) error {
 	buf = append(buf, ']')
 
 	// Add attributes
-	r.Attrs(func(a slog.Attr) bool {
+	writeAttr := func(a slog.Attr) bool {
 		if h.useColors {
 			buf = append(buf, h.bracketColor...)
 		}
@@ -205,7 +258,11 @@ func (h *BracketHandler) Handle(_ context.Context, r slog.Record) error {
 			buf = append(buf, colorReset...)
 		}
 		return true
-	})
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
 
 	buf = append(buf, '\n')
 	_, err := h.writer.Write(buf)
@@ -214,7 +271,9 @@ func (h *BracketHandler) Handle(_ context.Context, r slog.Record) error {
 
 // WithAttrs returns a new handler with additional attributes.
 func (h *BracketHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return h
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
 }
 
 // WithGroup returns a new handler with a group name.
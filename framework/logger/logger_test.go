@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewJSONIncludesBaseFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(Config{
+		Level:   "WARN",
+		Format:  "json",
+		Output:  &buf,
+		Service: "statigo",
+		Version: "v2",
+	})
+
+	log.Info("dropped below the level")
+	log.Warn("cache miss", "path", "/about")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("got %d records, want 1", len(lines))
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(lines[0], &record); err != nil {
+		t.Fatalf("record is not JSON: %v", err)
+	}
+	want := map[string]string{
+		"level":    "WARN",
+		"msg":      "cache miss",
+		"path":     "/about",
+		KeyService: "statigo",
+		KeyVersion: "v2",
+	}
+	for key, value := range want {
+		if got := record[key]; got != value {
+			t.Errorf("%s = %v, want %q", key, got, value)
+		}
+	}
+}
+
+func TestNewOmitsUnsetBaseFields(t *testing.T) {
+	var buf bytes.Buffer
+	New(Config{Format: "JSON", Output: &buf}).Info("started")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("record is not JSON: %v", err)
+	}
+	for _, key := range []string{KeyService, KeyVersion} {
+		if _, ok := record[key]; ok {
+			t.Errorf("unset %s added to the record", key)
+		}
+	}
+}
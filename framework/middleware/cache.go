@@ -2,54 +2,233 @@ package middleware
 
 import (
 	"bytes"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"statigo/framework/cache"
 	fwctx "statigo/framework/context"
 )
 
+// StrategyHeader is a response header handlers can set to override the cache
+// strategy for a single response (e.g. "dynamic" to skip caching an error fragment).
+// The cache middleware honors it and strips it before the response is sent.
+const StrategyHeader = "X-Cache-Strategy"
+
+// CacheStatus is the cache outcome reported in the status header.
+type CacheStatus string
+
+const (
+	CacheStatusHit   CacheStatus = "HIT"   // Served from a fresh cache entry
+	CacheStatusMiss  CacheStatus = "MISS"  // Rendered because no entry existed (or the cache was bypassed)
+	CacheStatusStale CacheStatus = "STALE" // Re-rendered because the entry was stale
+)
+
+// CacheMiddlewareConfig configures the cache middleware.
+type CacheMiddlewareConfig struct {
+	Debug          bool   // Emit X-Cache-Debug and Server-Timing headers (never enable in production)
+	BypassHeader   string // Request header forcing a live render; only honored when Debug is on
+	BypassParam    string // Query parameter forcing a live render (shareable URLs); only honored when Debug is on
+	BypassStore    bool   // Store the fresh render produced by a bypassed request
+	CacheRedirects bool   // Cache 301/308 responses and replay them on HITs
+	AgeHeader      bool   // Send an Age header with the seconds since the entry was rendered
+	WeakETags      bool   // Emit ETags with the W/ prefix; some proxies mishandle strong ETags
+	StatusHeader   string // Response header reporting the cache status (e.g. "CF-Cache-Status")
+	RootCanonical  string // Canonical path used for the bare "/" route, which has none (empty = skip)
+	RootStrategy   string // Cache strategy for the bare "/" route
+	// ImmutableAfter is how long an "immutable" entry's generation must stay unchanged
+	// before it is served with a year-long immutable Cache-Control (0 = never).
+	// Younger entries are sent with ImmutableFallbackMaxAge instead, so a page
+	// mistakenly marked immutable is not baked into client caches.
+	ImmutableAfter          time.Duration
+	ImmutableFallbackMaxAge time.Duration
+	// MinBodySize is the smallest body worth caching; smaller responses are served
+	// but not stored. Empty bodies are never cached and always logged as suspicious.
+	MinBodySize int
+	// StatusTokens overrides the StatusHeader value per status (e.g. to match
+	// existing monitoring); unmapped statuses are sent as HIT, MISS or STALE.
+	StatusTokens map[CacheStatus]string
+	// Formats lists the media types a route may be served as, negotiated from the
+	// Accept header (e.g. "text/html", "application/json"). The first is the default.
+	// With more than one format, each is cached separately and responses Vary on Accept.
+	Formats []string
+	// Themes lists the themes pages are rendered in server-side (e.g. "light", "dark").
+	// The first is the default. With more than one theme, each is cached separately;
+	// the preference is read from ThemeParam, then ThemeCookie.
+	Themes      []string
+	ThemeCookie string
+	ThemeParam  string
+	// PrefixStrategies assign a strategy to requests under a path prefix when no
+	// route set one, so pages outside routes.json (docs, ad-hoc handlers) can be
+	// cached under their own path. "{lang}" in a prefix matches the request language.
+	PrefixStrategies []PrefixStrategy
+}
+
+// PrefixStrategy maps a path prefix such as "/{lang}/docs/" to a cache strategy.
+type PrefixStrategy struct {
+	Prefix   string
+	Strategy string
+}
+
+// DefaultCacheMiddlewareConfig returns default configuration.
+func DefaultCacheMiddlewareConfig() CacheMiddlewareConfig {
+	return CacheMiddlewareConfig{
+		Debug:                   false,
+		BypassHeader:            "X-Cache-Bypass",
+		BypassParam:             "__fresh",
+		BypassStore:             false,
+		CacheRedirects:          false,
+		AgeHeader:               true,
+		WeakETags:               true,
+		StatusHeader:            "X-Cache",
+		ImmutableAfter:          0,
+		ImmutableFallbackMaxAge: time.Hour,
+		ThemeCookie:             "theme",
+		ThemeParam:              "theme",
+	}
+}
+
 // CacheMiddleware creates middleware that serves cached responses.
 // Supports ETag-based cache validation, returning 304 Not Modified
-// when the client's cached version matches.
+// when the client's cached version matches. Tombstoned pages are
+// answered with 410 Gone without invoking the handler.
 func CacheMiddleware(cacheManager *cache.Manager, logger *slog.Logger) func(http.Handler) http.Handler {
+	return CacheMiddlewareWithConfig(cacheManager, DefaultCacheMiddlewareConfig(), logger)
+}
+
+// CacheMiddlewareWithConfig creates cache middleware with custom configuration.
+func CacheMiddlewareWithConfig(cacheManager *cache.Manager, config CacheMiddlewareConfig, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The strategy override is meant for this middleware, never for clients
+			w = &strategyHeaderWriter{ResponseWriter: w}
+
 			// Only cache GET requests
 			if r.Method != http.MethodGet {
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			// Warm-up requests render live; the rebuild path stores the result itself
+			if fwctx.IsInternalRequest(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Get canonical path and language from context
 			canonical := fwctx.GetCanonicalPath(r.Context())
 			lang := fwctx.GetLanguage(r.Context())
 
+			// Give the bare root path a cache identity when configured
+			if canonical == "" && r.URL.Path == "/" && config.RootCanonical != "" {
+				canonical = config.RootCanonical
+				ctx := fwctx.SetCanonicalPath(r.Context(), canonical)
+				if config.RootStrategy != "" {
+					ctx = fwctx.SetStrategyResolution(ctx, config.RootStrategy, fwctx.StrategySourceRoute)
+				}
+				r = r.WithContext(ctx)
+			}
+
+			// Give ad-hoc routes a strategy (and cache identity) from prefix rules
+			if fwctx.GetStrategy(r.Context()) == "" {
+				if strategy, ok := matchPrefixStrategy(r.URL.Path, lang, config.PrefixStrategies); ok {
+					ctx := r.Context()
+					if canonical == "" {
+						canonical = r.URL.Path
+						ctx = fwctx.SetCanonicalPath(ctx, canonical)
+					}
+					r = r.WithContext(fwctx.SetStrategyResolution(ctx, strategy, fwctx.StrategySourcePrefix))
+				}
+			}
+
 			// Skip if no canonical path
 			if canonical == "" {
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			// Intentionally removed pages are gone for good, in every variant;
+			// tombstones are keyed by the page, not by format or theme
+			if cacheManager.IsTombstoned(cache.GetCacheKey(canonical, lang, nil)) {
+				http.Error(w, "Gone", http.StatusGone)
+				return
+			}
+
+			// Negotiate the response format so each one gets its own entry
+			if len(config.Formats) > 1 {
+				format := negotiateFormat(r.Header.Get("Accept"), config.Formats)
+				canonical = cache.WithVariant(canonical, formatVariant(format, config.Formats))
+				r = r.WithContext(fwctx.SetFormat(r.Context(), format))
+				w.Header().Add("Vary", "Accept")
+			}
+
+			// Resolve the theme preference so each theme gets its own entry
+			if len(config.Themes) > 1 {
+				theme := resolveTheme(r, config)
+				canonical = cache.WithVariant(canonical, themeVariant(theme, config.Themes))
+				r = r.WithContext(fwctx.SetTheme(r.Context(), theme))
+				if config.ThemeCookie != "" {
+					w.Header().Add("Vary", "Cookie")
+				}
+			}
+
 			// Generate cache key
 			cacheKey := cache.GetCacheKey(canonical, lang, nil)
 
+			// Debug bypass forces a live render without purging the cache
+			bypass := config.Debug && config.BypassHeader != "" && isTruthy(r.Header.Get(config.BypassHeader))
+			if config.Debug && config.BypassParam != "" {
+				var fresh bool
+				r, fresh = stripBypassParam(r, config.BypassParam)
+				bypass = bypass || fresh
+			}
+
 			// Try to get from cache
-			entry, found := cacheManager.Get(cacheKey)
+			var entry *cache.Entry
+			var found bool
+			lookupStart := time.Now()
+			if !bypass {
+				entry, found = cacheManager.Get(cacheKey)
+			}
+			lookupDuration := time.Since(lookupStart)
+
 			if found && !entry.IsStale() {
-				etag := `W/"` + entry.ETag + `"`
+				// Replay cached redirects without invoking the handler
+				if status, location := entry.Redirect(); status != 0 {
+					w.Header().Set("Location", location)
+					setCacheStatus(w, config, CacheStatusHit)
+					if config.Debug {
+						w.Header().Set("X-Cache-Debug", cacheDebugValue(cacheKey, entry.Strategy, r))
+						w.Header().Set("Server-Timing", serverTiming(
+							timingMetric{name: "cache-lookup", duration: lookupDuration},
+						))
+					}
+					w.WriteHeader(status)
+					return
+				}
+
+				_, entryETag, _ := entry.Snapshot()
+				etag := formatETag(entryETag, config.WeakETags)
+				status := entry.ResponseStatus()
+				if config.AgeHeader {
+					w.Header().Set("Age", ageValue(entry.Age()))
+				}
 
-				// Check If-None-Match for 304 Not Modified
-				if etagMatch(r.Header.Get("If-None-Match"), etag) {
+				// Check If-None-Match for 304 Not Modified; cached error pages are always replayed
+				if status == http.StatusOK && etagMatch(r.Header.Get("If-None-Match"), etag) {
 					w.Header().Set("ETag", etag)
-					w.Header().Set("Cache-Control", "no-cache")
+					w.Header().Set("Cache-Control", cacheControl(entry, config))
 					w.WriteHeader(http.StatusNotModified)
 					return
 				}
 
 				// Serve from cache
-				content, err := cache.GetDecompressedContent(entry)
+				decompressStart := time.Now()
+				content, entryETag, _, err := entry.DecompressedSnapshot()
+				decompressDuration := time.Since(decompressStart)
 				if err != nil {
 					logger.Warn("Failed to decompress cached content",
 						slog.String("key", cacheKey),
@@ -59,10 +238,30 @@ func CacheMiddleware(cacheManager *cache.Manager, logger *slog.Logger) func(http
 					return
 				}
 
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				w.Header().Set("X-Cache", "HIT")
+				// The entry may have been updated since the ETag check
+				etag = formatETag(entryETag, config.WeakETags)
+
+				w.Header().Set("Content-Type", entry.StoredContentType())
+				setCacheStatus(w, config, CacheStatusHit)
 				w.Header().Set("ETag", etag)
-				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("Cache-Control", cacheControl(entry, config))
+				if status == http.StatusOK {
+					w.Header().Set("Accept-Ranges", "bytes")
+				}
+				if config.Debug {
+					w.Header().Set("X-Cache-Debug", cacheDebugValue(cacheKey, entry.Strategy, r))
+					w.Header().Set("Server-Timing", serverTiming(
+						timingMetric{name: "cache-lookup", duration: lookupDuration},
+						timingMetric{name: "decompress", duration: decompressDuration},
+					))
+				}
+
+				// Honor single byte ranges; malformed ranges get the full body
+				if status == http.StatusOK && serveRange(w, r, content, etag) {
+					return
+				}
+
+				w.WriteHeader(status)
 				w.Write(content)
 				return
 			}
@@ -71,26 +270,82 @@ func CacheMiddleware(cacheManager *cache.Manager, logger *slog.Logger) func(http
 			strategy := fwctx.GetStrategy(r.Context())
 			if strategy == "" || strategy == "dynamic" {
 				// Don't cache dynamic content
+				if config.Debug {
+					w.Header().Set("Server-Timing", serverTiming(
+						timingMetric{name: "cache-lookup", duration: lookupDuration},
+					))
+				}
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Create response recorder that buffers the response
-			rec := &responseRecorder{
-				ResponseWriter: w,
-				body:           &bytes.Buffer{},
-				statusCode:     http.StatusOK,
+			if found {
+				setCacheStatus(w, config, CacheStatusStale)
+			} else {
+				setCacheStatus(w, config, CacheStatusMiss)
 			}
 
+			// Create response recorder that buffers the response
+			rec := cache.AcquireRecorder(w.Header())
+			defer cache.ReleaseRecorder(rec)
+
 			// Serve the request (response is buffered in the recorder)
+			renderStart := time.Now()
 			next.ServeHTTP(rec, r)
+			renderDuration := time.Since(renderStart)
 
-			// Only cache successful responses
-			if rec.statusCode == http.StatusOK {
-				content := rec.body.Bytes()
+			// The handler may have overridden the strategy for this response
+			if headerStrategy := w.Header().Get(StrategyHeader); headerStrategy != "" {
+				w.Header().Del(StrategyHeader)
+				if !fwctx.OverrideStrategyFrom(r.Context(), headerStrategy, fwctx.StrategySourceHeader) {
+					strategy = headerStrategy
+				}
+			}
+			if resolution, ok := fwctx.GetStrategyResolution(r.Context()); ok {
+				strategy = resolution.Strategy
+			}
+
+			if config.Debug {
+				w.Header().Set("X-Cache-Debug", cacheDebugValue(cacheKey, strategy, r))
+				w.Header().Set("Server-Timing", serverTiming(
+					timingMetric{name: "cache-lookup", duration: lookupDuration},
+					timingMetric{name: "render", duration: renderDuration},
+				))
+			}
+
+			// Only cache responses with an allowed status that are still cacheable,
+			// never a render abandoned because the request timed out or was cancelled
+			cacheable := strategy != "" && strategy != "dynamic" && (!bypass || config.BypassStore) && r.Context().Err() == nil
+			if config.CacheRedirects && cacheable && isPermanentRedirect(rec.StatusCode()) {
+				if err := cacheManager.SetRedirect(cacheKey, rec.StatusCode(), w.Header().Get("Location"), strategy, r.URL.Path); err != nil {
+					logger.Warn("Failed to cache redirect",
+						slog.String("key", cacheKey),
+						slog.String("error", err.Error()),
+					)
+				}
+			}
+			storable := cacheable && !isRedirect(rec.StatusCode()) && cacheManager.IsCacheableStatus(rec.StatusCode())
+			if storable && len(rec.Bytes()) == 0 {
+				// A cacheable route producing no output is likely a handler bug; don't mask it
+				storable = false
+				logger.Warn("Not caching empty response",
+					slog.String("key", cacheKey),
+					slog.Int("status", rec.StatusCode()),
+				)
+			} else if storable && len(rec.Bytes()) < config.MinBodySize {
+				storable = false
+				logger.Debug("Not caching response below minimum size",
+					slog.String("key", cacheKey),
+					slog.Int("size", len(rec.Bytes())),
+				)
+			}
+			if storable {
+				// Copy out of the pooled buffer, since the cache retains the content
+				content := bytes.Clone(rec.Bytes())
 
-				// Store in cache
-				if err := cacheManager.Set(cacheKey, content, strategy, r.URL.Path); err != nil {
+				// Store in cache along with the type the handler served it as
+				contentType := w.Header().Get("Content-Type")
+				if err := cacheManager.SetWithStatus(cacheKey, content, rec.StatusCode(), contentType, strategy, r.URL.Path); err != nil {
 					logger.Warn("Failed to cache response",
 						slog.String("key", cacheKey),
 						slog.String("error", err.Error()),
@@ -103,20 +358,162 @@ func CacheMiddleware(cacheManager *cache.Manager, logger *slog.Logger) func(http
 
 					// Set ETag from the newly cached entry
 					if cachedEntry, ok := cacheManager.Get(cacheKey); ok {
-						w.Header().Set("ETag", `W/"`+cachedEntry.ETag+`"`)
-						w.Header().Set("Cache-Control", "no-cache")
+						_, cachedETag, _ := cachedEntry.Snapshot()
+						w.Header().Set("ETag", formatETag(cachedETag, config.WeakETags))
+						w.Header().Set("Cache-Control", cacheControl(cachedEntry, config))
 					}
 				}
 			}
 
 			// Write the buffered response to the underlying writer
-			w.WriteHeader(rec.statusCode)
-			w.Write(rec.body.Bytes())
+			w.WriteHeader(rec.StatusCode())
+			w.Write(rec.Bytes())
 		})
 	}
 }
 
+// strategyHeaderWriter removes StrategyHeader before the response headers are
+// sent, including on paths that bypass the response recorder.
+type strategyHeaderWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *strategyHeaderWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Del(StrategyHeader)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *strategyHeaderWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush.
+func (w *strategyHeaderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// matchPrefixStrategy returns the strategy of the longest prefix rule matching path.
+func matchPrefixStrategy(path, lang string, rules []PrefixStrategy) (string, bool) {
+	var strategy string
+	longest := -1
+	for _, rule := range rules {
+		prefix := strings.ReplaceAll(rule.Prefix, "{lang}", lang)
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			strategy = rule.Strategy
+			longest = len(prefix)
+		}
+	}
+	return strategy, longest >= 0
+}
+
+// isTruthy reports whether a header value enables a flag ("1", "true", ...).
+func isTruthy(value string) bool {
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
+
+// stripBypassParam removes the bypass query parameter so the handler never sees it,
+// reporting whether it requested a live render ("?__fresh" or "?__fresh=1").
+func stripBypassParam(r *http.Request, param string) (*http.Request, bool) {
+	query := r.URL.Query()
+	if !query.Has(param) {
+		return r, false
+	}
+
+	value := query.Get(param)
+	query.Del(param)
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = query.Encode()
+	r.RequestURI = r.URL.RequestURI()
+
+	return r, value == "" || isTruthy(value)
+}
+
+// setCacheStatus reports the cache status using the configured header and tokens.
+func setCacheStatus(w http.ResponseWriter, config CacheMiddlewareConfig, status CacheStatus) {
+	if config.StatusHeader == "" {
+		return
+	}
+	token, ok := config.StatusTokens[status]
+	if !ok {
+		token = string(status)
+	}
+	w.Header().Set(config.StatusHeader, token)
+}
+
+// ageValue formats an entry age as an Age header value in whole seconds, floored at 0.
+func ageValue(age time.Duration) string {
+	if age < 0 {
+		age = 0
+	}
+	return strconv.FormatInt(int64(age/time.Second), 10)
+}
+
+// cacheControl returns the Cache-Control value for a cached entry.
+// Immutable entries earn a year-long immutable header once their generation has
+// been stable for ImmutableAfter; everything else is revalidated via its ETag.
+func cacheControl(entry *cache.Entry, config CacheMiddlewareConfig) string {
+	if entry.Strategy != "immutable" || config.ImmutableAfter <= 0 {
+		return "no-cache"
+	}
+	if entry.Age() >= config.ImmutableAfter {
+		return "public, max-age=31536000, immutable"
+	}
+	return "public, max-age=" + strconv.FormatInt(int64(config.ImmutableFallbackMaxAge/time.Second), 10)
+}
+
+// isRedirect reports whether the status is a 3xx redirect, which is never cached as a page.
+func isRedirect(status int) bool {
+	return status >= 300 && status < 400
+}
+
+// isPermanentRedirect reports whether the status is a cacheable redirect (301 or 308).
+func isPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// cacheDebugValue formats the X-Cache-Debug header value.
+func cacheDebugValue(cacheKey, strategy string, r *http.Request) string {
+	value := fmt.Sprintf("key=%s; strategy=%s", cacheKey, strategy)
+	if resolution, ok := fwctx.GetStrategyResolution(r.Context()); ok {
+		value += "; source=" + string(resolution.Source)
+	}
+	return value
+}
+
+// timingMetric is a single Server-Timing metric.
+type timingMetric struct {
+	name     string
+	duration time.Duration
+}
+
+// serverTiming formats metrics as a Server-Timing header value in milliseconds.
+func serverTiming(metrics ...timingMetric) string {
+	parts := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		ms := float64(metric.duration.Microseconds()) / 1000
+		parts = append(parts, fmt.Sprintf("%s;dur=%.3f", metric.name, ms))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatETag quotes an entry hash as an entity tag, weak-prefixed when requested.
+func formatETag(hash string, weak bool) string {
+	if weak {
+		return `W/"` + hash + `"`
+	}
+	return `"` + hash + `"`
+}
+
 // etagMatch checks if the If-None-Match header value matches the given ETag.
+// Uses weak comparison, so a proxy adding or stripping the W/ prefix still matches.
 func etagMatch(ifNoneMatch, etag string) bool {
 	if ifNoneMatch == "" {
 		return false
@@ -125,34 +522,9 @@ func etagMatch(ifNoneMatch, etag string) bool {
 		return true
 	}
 	for _, candidate := range strings.Split(ifNoneMatch, ",") {
-		if strings.TrimSpace(candidate) == etag {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
 			return true
 		}
 	}
 	return false
 }
-
-// responseRecorder captures response data for caching without writing through.
-// This allows setting headers (like ETag) before the response is sent.
-type responseRecorder struct {
-	http.ResponseWriter
-	body        *bytes.Buffer
-	statusCode  int
-	wroteHeader bool
-}
-
-// WriteHeader captures the status code without writing to the underlying writer.
-func (r *responseRecorder) WriteHeader(statusCode int) {
-	if !r.wroteHeader {
-		r.statusCode = statusCode
-		r.wroteHeader = true
-	}
-}
-
-// Write captures the response body without writing to the underlying writer.
-func (r *responseRecorder) Write(b []byte) (int, error) {
-	if !r.wroteHeader {
-		r.wroteHeader = true
-	}
-	return r.body.Write(b)
-}
@@ -0,0 +1,685 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"statigo/framework/cache"
+	fwctx "statigo/framework/context"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// newTestManager returns a manager whose entries stay in memory, so background
+// writes never race the removal of the test's cache directory.
+func newTestManager(t *testing.T) *cache.Manager {
+	t.Helper()
+	manager, err := cache.NewManager(t.TempDir(), discardLogger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	manager.SetMemoryOnlyStrategies("static", "incremental", "immutable")
+	return manager
+}
+
+// countingHandler writes body and counts how often it was invoked.
+func countingHandler(renders *atomic.Int32, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, body)
+	})
+}
+
+// withRoute sets the canonical path, language and strategy the route middleware would.
+func withRoute(canonical, lang, strategy string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := fwctx.SetCanonicalPath(r.Context(), canonical)
+		ctx = fwctx.SetLanguage(ctx, lang)
+		if strategy != "" {
+			ctx = fwctx.SetStrategyResolution(ctx, strategy, fwctx.StrategySourceRoute)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// serve runs a GET for path through handler and returns the recorded response.
+func serve(handler http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCacheMiddlewareIgnoresBootstrapHeader(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+
+	serve(handler, "/about", nil)
+
+	// A client-supplied header must not skip the cache
+	header := http.Header{"X-Internal-Bootstrap": {"true"}}
+	for i := 0; i < 3; i++ {
+		rec := serve(handler, "/about", header)
+		if got := rec.Header().Get("X-Cache"); got != "HIT" {
+			t.Fatalf("request %d: X-Cache = %q, want HIT", i, got)
+		}
+	}
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1", got)
+	}
+}
+
+func TestCacheMiddlewareRendersInternalRequests(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+
+	serve(handler, "/about", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/about", nil)
+	req = req.WithContext(fwctx.MarkInternalRequest(req.Context()))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := renders.Load(); got != 2 {
+		t.Errorf("renders = %d, want 2", got)
+	}
+}
+
+func TestCacheMiddlewareTombstone(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := withRoute("/old", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>old</p>")))
+
+	serve(handler, "/old", nil)
+	if err := manager.Tombstone("/old", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+
+	rec := serve(handler, "/old", nil)
+	if rec.Code != http.StatusGone {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusGone)
+	}
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1", got)
+	}
+}
+
+func TestCacheMiddlewareServerTiming(t *testing.T) {
+	metricNames := func(header string) []string {
+		var names []string
+		for _, part := range strings.Split(header, ", ") {
+			names = append(names, strings.Split(part, ";")[0])
+		}
+		return names
+	}
+
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.Debug = true
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+
+	miss := serve(handler, "/about", nil).Header().Get("Server-Timing")
+	if got := metricNames(miss); !reflect.DeepEqual(got, []string{"cache-lookup", "render"}) {
+		t.Errorf("MISS metrics = %v from %q", got, miss)
+	}
+	hit := serve(handler, "/about", nil).Header().Get("Server-Timing")
+	if got := metricNames(hit); !reflect.DeepEqual(got, []string{"cache-lookup", "decompress"}) {
+		t.Errorf("HIT metrics = %v from %q", got, hit)
+	}
+
+	// Timings are only exposed in debug mode
+	quiet := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+	if got := serve(quiet, "/about", nil).Header().Get("Server-Timing"); got != "" {
+		t.Errorf("Server-Timing = %q without debug, want none", got)
+	}
+}
+
+func TestCacheMiddlewareRecordsStrategySource(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.Debug = true
+	var resolution fwctx.StrategyResolution
+	overriding := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fwctx.OverrideStrategy(r.Context(), "incremental")
+		resolution, _ = fwctx.GetStrategyResolution(r.Context())
+		io.WriteString(w, "<p>news</p>")
+	})
+	handler := withRoute("/news", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(overriding))
+
+	rec := serve(handler, "/news", nil)
+
+	want := fwctx.StrategyResolution{Strategy: "incremental", Source: fwctx.StrategySourceHandler}
+	if resolution != want {
+		t.Errorf("resolution = %+v, want %+v", resolution, want)
+	}
+	if got := rec.Header().Get("X-Cache-Debug"); !strings.Contains(got, "strategy=incremental; source=handler") {
+		t.Errorf("X-Cache-Debug = %q, want the handler override", got)
+	}
+	entry, ok := manager.Get(cache.GetCacheKey("/news", "en", nil))
+	if !ok {
+		t.Fatal("response not cached")
+	}
+	if entry.Strategy != "incremental" {
+		t.Errorf("cached strategy = %q, want incremental", entry.Strategy)
+	}
+}
+
+func TestCacheMiddlewareStrategyHeader(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	personalized := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		w.Header().Set(StrategyHeader, "dynamic")
+		io.WriteString(w, "<p>hello, visitor</p>")
+	})
+	handler := withRoute("/account", "en", "static",
+		CacheMiddleware(manager, discardLogger)(personalized))
+
+	for i := 0; i < 2; i++ {
+		rec := serve(handler, "/account", nil)
+		if got := rec.Header().Get(StrategyHeader); got != "" {
+			t.Errorf("%s leaked to the client: %q", StrategyHeader, got)
+		}
+	}
+
+	if got := renders.Load(); got != 2 {
+		t.Errorf("renders = %d, want 2", got)
+	}
+	if _, ok := manager.Get(cache.GetCacheKey("/account", "en", nil)); ok {
+		t.Error("response overridden to dynamic was cached")
+	}
+}
+
+func TestCacheMiddlewareStrategyHeaderNeverLeaks(t *testing.T) {
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(StrategyHeader, "static")
+		io.WriteString(w, "<p>page</p>")
+	})
+
+	tests := []struct {
+		name      string
+		canonical string
+		strategy  string
+		method    string
+	}{
+		{"dynamic route", "/search", "dynamic", http.MethodGet},
+		{"no strategy", "/search", "", http.MethodGet},
+		{"no canonical path", "", "", http.MethodGet},
+		{"non-GET", "/search", "static", http.MethodPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := withRoute(tt.canonical, "en", tt.strategy,
+				CacheMiddleware(newTestManager(t), discardLogger)(page))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/search", nil))
+
+			// Result holds the headers as they were when written
+			if got := rec.Result().Header.Get(StrategyHeader); got != "" {
+				t.Errorf("%s leaked to the client: %q", StrategyHeader, got)
+			}
+			if rec.Body.String() != "<p>page</p>" {
+				t.Errorf("body = %q, want the rendered page", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareBypassHeader(t *testing.T) {
+	bypass := http.Header{"X-Cache-Bypass": {"1"}}
+
+	tests := []struct {
+		name        string
+		debug       bool
+		wantRenders int32
+		wantStatus  string
+	}{
+		{"enabled in debug", true, 2, "MISS"},
+		{"ignored without debug", false, 1, "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			config := DefaultCacheMiddlewareConfig()
+			config.Debug = tt.debug
+			var renders atomic.Int32
+			handler := withRoute("/about", "en", "static",
+				CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+
+			serve(handler, "/about", nil)
+			rec := serve(handler, "/about", bypass)
+
+			if got := renders.Load(); got != tt.wantRenders {
+				t.Errorf("renders = %d, want %d", got, tt.wantRenders)
+			}
+			if got := rec.Header().Get("X-Cache"); got != tt.wantStatus {
+				t.Errorf("X-Cache = %q, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareBypassParam(t *testing.T) {
+	tests := []struct {
+		name        string
+		debug       bool
+		target      string
+		wantRenders int32
+		wantStatus  string
+	}{
+		{"enabled in debug", true, "/about?__fresh=1", 2, "MISS"},
+		{"bare parameter", true, "/about?__fresh", 2, "MISS"},
+		{"falsy value", true, "/about?__fresh=0", 1, "HIT"},
+		{"ignored without debug", false, "/about?__fresh=1", 1, "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			config := DefaultCacheMiddlewareConfig()
+			config.Debug = tt.debug
+			var renders atomic.Int32
+			var query string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.RawQuery
+				countingHandler(&renders, "<p>about</p>").ServeHTTP(w, r)
+			})
+			handler := withRoute("/about", "en", "static",
+				CacheMiddlewareWithConfig(manager, config, discardLogger)(next))
+
+			serve(handler, "/about?page=2", nil)
+			rec := serve(handler, tt.target+"&page=2", nil)
+
+			if got := renders.Load(); got != tt.wantRenders {
+				t.Errorf("renders = %d, want %d", got, tt.wantRenders)
+			}
+			if got := rec.Header().Get("X-Cache"); got != tt.wantStatus {
+				t.Errorf("X-Cache = %q, want %s", got, tt.wantStatus)
+			}
+			if tt.debug && query != "page=2" {
+				t.Errorf("handler saw query %q, want the bypass parameter stripped", query)
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareReplaysRedirects(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.CacheRedirects = true
+	var renders atomic.Int32
+	moved := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		http.Redirect(w, r, "/en/about-us", http.StatusMovedPermanently)
+	})
+	handler := withRoute("/about", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(moved))
+
+	serve(handler, "/en/about", nil)
+	rec := serve(handler, "/en/about", nil)
+
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1", got)
+	}
+	if rec.Code != http.StatusMovedPermanently {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMovedPermanently)
+	}
+	if got := rec.Header().Get("Location"); got != "/en/about-us" {
+		t.Errorf("Location = %q, want /en/about-us", got)
+	}
+	if got := rec.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("X-Cache = %q, want HIT", got)
+	}
+}
+
+func TestCacheMiddlewareSkipsRejectedContent(t *testing.T) {
+	manager := newTestManager(t)
+	manager.SetContentValidator(cache.RejectMarkers(`<div class="error-page">`))
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, `<div class="error-page">Something went wrong</div>`)))
+
+	for i := 0; i < 2; i++ {
+		rec := serve(handler, "/about", nil)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Something went wrong") {
+			t.Errorf("request %d: response = %d %q, want the rendered page", i, rec.Code, rec.Body.String())
+		}
+	}
+
+	if got := renders.Load(); got != 2 {
+		t.Errorf("renders = %d, want 2", got)
+	}
+	if _, ok := manager.Get(cache.GetCacheKey("/about", "en", nil)); ok {
+		t.Error("error page was cached")
+	}
+}
+
+func TestCacheMiddlewareAgeHeader(t *testing.T) {
+	manager := newTestManager(t)
+	key := cache.GetCacheKey("/about", "en", nil)
+	if err := manager.SetSync(key, []byte("<p>about</p>"), "static", "/about"); err != nil {
+		t.Fatalf("SetSync: %v", err)
+	}
+	entry, _ := manager.Get(key)
+	entry.RenderedAt = time.Now().Add(-90 * time.Second)
+
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+	if got := serve(handler, "/about", nil).Header().Get("Age"); got != "90" {
+		t.Errorf("Age = %q, want 90", got)
+	}
+
+	config := DefaultCacheMiddlewareConfig()
+	config.AgeHeader = false
+	handler = withRoute("/about", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+	if got := serve(handler, "/about", nil).Header().Get("Age"); got != "" {
+		t.Errorf("Age = %q with the header disabled, want none", got)
+	}
+}
+
+func TestCacheMiddlewareRootCanonical(t *testing.T) {
+	tests := []struct {
+		name        string
+		canonical   string
+		wantRenders int32
+		wantStatus  string
+	}{
+		{"mapped", "/home", 1, "HIT"},
+		{"unmapped", "", 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			config := DefaultCacheMiddlewareConfig()
+			config.RootCanonical = tt.canonical
+			config.RootStrategy = "static"
+			var renders atomic.Int32
+			handler := CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>home</p>"))
+
+			serve(handler, "/", nil)
+			rec := serve(handler, "/", nil)
+
+			if got := renders.Load(); got != tt.wantRenders {
+				t.Errorf("renders = %d, want %d", got, tt.wantRenders)
+			}
+			if got := rec.Header().Get("X-Cache"); got != tt.wantStatus {
+				t.Errorf("X-Cache = %q, want %q", got, tt.wantStatus)
+			}
+			if _, ok := manager.Get(cache.GetCacheKey("/home", "en", nil)); ok != (tt.canonical != "") {
+				t.Errorf("root entry cached = %v, want %v", ok, tt.canonical != "")
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareImmutableCacheControl(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.ImmutableAfter = 24 * time.Hour
+
+	var renders atomic.Int32
+	next := countingHandler(&renders, "<p>logo</p>")
+	handler := withRoute("/logo", "en", "immutable", CacheMiddlewareWithConfig(manager, config, discardLogger)(next))
+	static := withRoute("/about", "en", "static", CacheMiddlewareWithConfig(manager, config, discardLogger)(next))
+
+	// A new immutable entry gets the conservative header until it proves stable
+	if got := serve(handler, "/logo", nil).Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("new entry Cache-Control = %q", got)
+	}
+	if got := serve(handler, "/logo", nil).Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("new entry HIT Cache-Control = %q", got)
+	}
+
+	entry, _ := manager.Get(cache.GetCacheKey("/logo", "en", nil))
+	entry.RenderedAt = time.Now().Add(-25 * time.Hour)
+	rec := serve(handler, "/logo", nil)
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("stable entry Cache-Control = %q", got)
+	}
+	revalidated := serve(handler, "/logo", http.Header{"If-None-Match": {rec.Header().Get("ETag")}})
+	if got := revalidated.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("304 Cache-Control = %q", got)
+	}
+
+	// Other strategies always revalidate
+	serve(static, "/about", nil)
+	if got := serve(static, "/about", nil).Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("static Cache-Control = %q, want no-cache", got)
+	}
+}
+
+func TestCacheMiddlewareETags(t *testing.T) {
+	tests := []struct {
+		name string
+		weak bool
+	}{
+		{"weak", true},
+		{"strong", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			config := DefaultCacheMiddlewareConfig()
+			config.WeakETags = tt.weak
+			var renders atomic.Int32
+			handler := withRoute("/about", "en", "static",
+				CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+
+			etag := serve(handler, "/about", nil).Header().Get("ETag")
+			if got := strings.HasPrefix(etag, "W/"); got != tt.weak {
+				t.Fatalf("ETag = %q, weak prefix %v, want %v", etag, got, tt.weak)
+			}
+
+			// Revalidation matches with or without the W/ prefix a proxy may have changed
+			strong := strings.TrimPrefix(etag, "W/")
+			for _, ifNoneMatch := range []string{etag, strong, "W/" + strong, `"other", ` + etag} {
+				rec := serve(handler, "/about", http.Header{"If-None-Match": {ifNoneMatch}})
+				if rec.Code != http.StatusNotModified {
+					t.Errorf("If-None-Match %q: status = %d, want %d", ifNoneMatch, rec.Code, http.StatusNotModified)
+				}
+			}
+			if rec := serve(handler, "/about", http.Header{"If-None-Match": {`W/"other"`}}); rec.Code != http.StatusOK {
+				t.Errorf("mismatched If-None-Match: status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareStatusHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		tokens map[CacheStatus]string
+		want   []string // MISS, HIT, STALE
+	}{
+		{"default", "X-Cache", nil, []string{"MISS", "HIT", "STALE"}},
+		{"custom header", "CF-Cache-Status", nil, []string{"MISS", "HIT", "STALE"}},
+		{"custom tokens", "X-Cache", map[CacheStatus]string{CacheStatusHit: "TCP_HIT", CacheStatusStale: "REVALIDATED"},
+			[]string{"MISS", "TCP_HIT", "REVALIDATED"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			config := DefaultCacheMiddlewareConfig()
+			config.StatusHeader = tt.header
+			config.StatusTokens = tt.tokens
+			var renders atomic.Int32
+			handler := withRoute("/about", "en", "static",
+				CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+
+			var got []string
+			got = append(got, serve(handler, "/about", nil).Header().Get(tt.header))
+			got = append(got, serve(handler, "/about", nil).Header().Get(tt.header))
+			manager.MarkAllStale(false)
+			got = append(got, serve(handler, "/about", nil).Header().Get(tt.header))
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareCacheableStatuses(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		wantRenders int32
+		wantCache   string
+	}{
+		{"default", nil, 2, "MISS"},
+		{"404 allowed", []int{http.StatusOK, http.StatusNotFound}, 1, "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newTestManager(t)
+			if tt.statuses != nil {
+				manager.SetCacheableStatuses(tt.statuses...)
+			}
+			var renders atomic.Int32
+			notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				renders.Add(1)
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, "<p>not found</p>")
+			})
+			handler := withRoute("/missing", "en", "static",
+				CacheMiddleware(manager, discardLogger)(notFound))
+
+			serve(handler, "/missing", nil)
+			rec := serve(handler, "/missing", nil)
+
+			if got := renders.Load(); got != tt.wantRenders {
+				t.Errorf("renders = %d, want %d", got, tt.wantRenders)
+			}
+			if got := rec.Header().Get("X-Cache"); got != tt.wantCache {
+				t.Errorf("X-Cache = %q, want %s", got, tt.wantCache)
+			}
+			// Replayed with the original status and body
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+			}
+			if rec.Body.String() != "<p>not found</p>" {
+				t.Errorf("body = %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareSkipsSmallBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		minSize     int
+		wantRenders int32
+		wantWarning bool
+	}{
+		{"empty body", "", 0, 2, true},
+		{"below minimum", "<p>hi</p>", 64, 2, false},
+		{"at minimum", "<p>hi</p>", len("<p>hi</p>"), 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs strings.Builder
+			logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+			manager := newTestManager(t)
+			config := DefaultCacheMiddlewareConfig()
+			config.MinBodySize = tt.minSize
+			var renders atomic.Int32
+			handler := withRoute("/about", "en", "static",
+				CacheMiddlewareWithConfig(manager, config, logger)(countingHandler(&renders, tt.body)))
+
+			serve(handler, "/about", nil)
+			rec := serve(handler, "/about", nil)
+
+			if got := renders.Load(); got != tt.wantRenders {
+				t.Errorf("renders = %d, want %d", got, tt.wantRenders)
+			}
+			if rec.Code != http.StatusOK || rec.Body.String() != tt.body {
+				t.Errorf("response = %d %q, want the body served", rec.Code, rec.Body.String())
+			}
+			if got := strings.Contains(logs.String(), "Not caching empty response"); got != tt.wantWarning {
+				t.Errorf("warning logged = %v, want %v:\n%s", got, tt.wantWarning, logs.String())
+			}
+		})
+	}
+}
+
+func TestMatchPrefixStrategy(t *testing.T) {
+	rules := []PrefixStrategy{
+		{Prefix: "/{lang}/docs/", Strategy: "static"},
+		{Prefix: "/{lang}/docs/drafts/", Strategy: "dynamic"},
+	}
+
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{"/en/docs/intro", "static", true},
+		{"/en/docs/drafts/next", "dynamic", true}, // Longest prefix wins
+		{"/tr/docs/intro", "", false},             // {lang} is the request language
+		{"/en/blog/intro", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := matchPrefixStrategy(tt.path, "en", rules)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("matchPrefixStrategy(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCacheMiddlewarePrefixStrategies(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.PrefixStrategies = []PrefixStrategy{{Prefix: "/{lang}/docs/", Strategy: "static"}}
+
+	var renders atomic.Int32
+	// No canonical path or strategy: the page is outside the route table
+	handler := withRoute("", "en", "",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>docs</p>")))
+
+	serve(handler, "/en/docs/intro", nil)
+	rec := serve(handler, "/en/docs/intro", nil)
+	if got := rec.Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("X-Cache = %q, want HIT for a prefix-matched page", got)
+	}
+	if _, found := manager.Get("/en/docs/intro:en"); !found {
+		t.Error("prefix-matched page not cached under its own path")
+	}
+
+	serve(handler, "/en/blog/intro", nil)
+	serve(handler, "/en/blog/intro", nil)
+	if got := renders.Load(); got != 3 {
+		t.Errorf("renders = %d, want 3 (unmatched paths are not cached)", got)
+	}
+}
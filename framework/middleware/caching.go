@@ -27,10 +27,11 @@ func CachingHeaders(devMode bool) func(http.Handler) http.Handler {
 
 	// Config files that should have shorter cache
 	configFiles := map[string]bool{
-		"robots.txt":       true,
-		"manifest.json":    true,
-		"sitemap.xml":      true,
-		"site.webmanifest": true,
+		"robots.txt":        true,
+		"manifest.json":     true,
+		"sitemap.xml":       true,
+		"sitemap_index.xml": true,
+		"site.webmanifest":  true,
 	}
 
 	return func(next http.Handler) http.Handler {
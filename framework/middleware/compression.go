@@ -15,6 +15,13 @@ const (
 	compressionGzip   = "gzip"
 )
 
+// etagSuffixes maps content encodings to the suffix appended to their ETags,
+// so each encoded variant of a response carries a distinct entity tag.
+var etagSuffixes = map[string]string{
+	compressionBrotli: "-br",
+	compressionGzip:   "-gz",
+}
+
 // CompressibleContentTypes defines which content types should be compressed.
 var CompressibleContentTypes = map[string]bool{
 	"text/html":              true,
@@ -98,6 +105,16 @@ func Compression() func(http.Handler) http.Handler {
 				return
 			}
 
+			// Validators from a previously encoded response carry the encoding suffix;
+			// strip it so downstream ETag comparisons see the identity tag
+			etagSuffix := ""
+			if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+				if stripped, ok := stripETagSuffix(ifNoneMatch, etagSuffixes[compressionType]); ok {
+					r.Header.Set("If-None-Match", stripped)
+					etagSuffix = etagSuffixes[compressionType]
+				}
+			}
+
 			// Create a wrapper to intercept the response
 			crw := &compressionResponseWriter{
 				ResponseWriter:  w,
@@ -110,6 +127,7 @@ func Compression() func(http.Handler) http.Handler {
 				compressionResponseWriter: crw,
 				originalWriter:            w,
 				compressionType:           compressionType,
+				notModifiedSuffix:         etagSuffix,
 			}
 
 			next.ServeHTTP(wrappedWriter, r)
@@ -125,15 +143,20 @@ func Compression() func(http.Handler) http.Handler {
 // contentTypeCheckWriter waits for WriteHeader or first Write to determine if compression should be used.
 type contentTypeCheckWriter struct {
 	*compressionResponseWriter
-	originalWriter  http.ResponseWriter
-	compressionType string
-	checkedType     bool
+	originalWriter    http.ResponseWriter
+	compressionType   string
+	checkedType       bool
+	notModifiedSuffix string // ETag suffix restored on 304s validated against an encoded variant
 }
 
 func (w *contentTypeCheckWriter) WriteHeader(code int) {
 	if !w.checkedType {
 		w.setupCompression()
 	}
+	if code == http.StatusNotModified && w.notModifiedSuffix != "" {
+		header := w.originalWriter.Header()
+		header.Set("ETag", suffixETag(header.Get("ETag"), w.notModifiedSuffix))
+	}
 	w.compressionResponseWriter.WriteHeader(code)
 }
 
@@ -166,6 +189,11 @@ func (w *contentTypeCheckWriter) setupCompression() {
 		return
 	}
 
+	// Ranged responses describe identity bytes and must not be re-encoded
+	if w.originalWriter.Header().Get("Content-Range") != "" {
+		return
+	}
+
 	// Set up compression writer
 	switch w.compressionType {
 	case compressionBrotli:
@@ -173,15 +201,54 @@ func (w *contentTypeCheckWriter) setupCompression() {
 		bw.Reset(w.originalWriter)
 		w.compressionResponseWriter.Writer = bw
 		w.compressionResponseWriter.compressionType = compressionBrotli
+		w.suffixETag()
 
 	case compressionGzip:
 		gw := gzipWriterPool.Get().(*gzip.Writer)
 		gw.Reset(w.originalWriter)
 		w.compressionResponseWriter.Writer = gw
 		w.compressionResponseWriter.compressionType = compressionGzip
+		w.suffixETag()
 	}
 }
 
+// suffixETag marks the response ETag with the suffix of the encoding being applied.
+func (w *contentTypeCheckWriter) suffixETag() {
+	header := w.originalWriter.Header()
+	if etag := header.Get("ETag"); etag != "" {
+		header.Set("ETag", suffixETag(etag, etagSuffixes[w.compressionType]))
+	}
+}
+
+// suffixETag appends a suffix inside the closing quote of an entity tag.
+func suffixETag(etag, suffix string) string {
+	if !strings.HasSuffix(etag, `"`) || strings.HasSuffix(etag, suffix+`"`) {
+		return etag
+	}
+	return etag[:len(etag)-1] + suffix + `"`
+}
+
+// stripETagSuffix removes an encoding suffix from each entity tag in an
+// If-None-Match value. It reports whether any tag carried the suffix.
+func stripETagSuffix(ifNoneMatch, suffix string) (string, bool) {
+	if suffix == "" {
+		return ifNoneMatch, false
+	}
+
+	stripped := false
+	tags := strings.Split(ifNoneMatch, ",")
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if trimmed, found := strings.CutSuffix(tag, suffix+`"`); found {
+			tag = trimmed + `"`
+			stripped = true
+		}
+		tags[i] = tag
+	}
+
+	return strings.Join(tags, ", "), stripped
+}
+
 // selectCompression chooses the best compression method based on Accept-Encoding header.
 // Prefers Brotli over gzip.
 func selectCompression(acceptEncoding string) string {
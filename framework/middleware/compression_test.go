@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCompressionSuffixesETagPerEncoding(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := Compression()(withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about us</p>"))))
+
+	serve(handler, "/about", nil)
+	br := serve(handler, "/about", http.Header{"Accept-Encoding": {"br"}})
+	gz := serve(handler, "/about", http.Header{"Accept-Encoding": {"gzip"}})
+
+	brETag, gzETag := br.Header().Get("ETag"), gz.Header().Get("ETag")
+	if !strings.HasSuffix(brETag, `-br"`) || !strings.HasSuffix(gzETag, `-gz"`) {
+		t.Fatalf("ETags = %q (br), %q (gzip); want encoding suffixes", brETag, gzETag)
+	}
+
+	tests := []struct {
+		name       string
+		encoding   string
+		etag       string
+		wantStatus int
+		wantETag   string
+	}{
+		{"br validator with br", "br", brETag, http.StatusNotModified, brETag},
+		{"gzip validator with gzip", "gzip", gzETag, http.StatusNotModified, gzETag},
+		{"br validator with gzip", "gzip", brETag, http.StatusOK, gzETag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, "/about", http.Header{
+				"Accept-Encoding": {tt.encoding},
+				"If-None-Match":   {tt.etag},
+			})
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	fwctx "statigo/framework/context"
+)
+
+// CanonicalHostConfig configures the canonical host middleware.
+type CanonicalHostConfig struct {
+	Host         string   // Canonical host, e.g. "example.com" (include the port if non-standard); empty disables
+	Scheme       string   // Scheme of the redirect target; empty keeps the request's scheme
+	TrustProxy   bool     // Honor X-Forwarded-Proto; only enable behind a proxy that sets it
+	SkipPrefixes []string // Path prefixes served on any host, e.g. health checks probing by IP
+}
+
+// DefaultCanonicalHostConfig returns default configuration.
+func DefaultCanonicalHostConfig() CanonicalHostConfig {
+	return CanonicalHostConfig{
+		Host:         "",
+		Scheme:       "",
+		TrustProxy:   false,
+		SkipPrefixes: []string{"/health/"},
+	}
+}
+
+// CanonicalHost redirects requests arriving on another host (www vs apex, a raw IP)
+// to the same path and query on the canonical host, so pages are indexed and
+// cached under a single origin. Internal bootstrap and revalidation requests are
+// never redirected.
+func CanonicalHost(config CanonicalHostConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Host == "" || strings.EqualFold(r.Host, config.Host) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Warm-up and revalidation requests are rendered in-process with whatever host httptest assigns
+			if fwctx.IsInternalRequest(r.Context()) || fwctx.IsRevalidationRequest(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, prefix := range config.SkipPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			scheme := config.Scheme
+			if scheme == "" {
+				scheme = requestScheme(r, config.TrustProxy)
+			}
+
+			// 308 keeps the method and body of non-GET requests
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+
+			http.Redirect(w, r, scheme+"://"+config.Host+r.URL.RequestURI(), status)
+		})
+	}
+}
+
+// requestScheme returns the scheme the client used. X-Forwarded-Proto from a
+// TLS-terminating proxy is only honored when trusted, since clients can set it.
+func requestScheme(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		proto := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0]))
+		if proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fwctx "statigo/framework/context"
+)
+
+func TestCanonicalHost(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		config     CanonicalHostConfig
+		method     string
+		target     string
+		proto      string
+		internal   bool
+		wantStatus int
+		wantTarget string
+	}{
+		{
+			name:       "canonical host",
+			config:     CanonicalHostConfig{Host: "example.com"},
+			target:     "http://example.com/about",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "other host",
+			config:     CanonicalHostConfig{Host: "example.com"},
+			target:     "http://www.example.com/about?page=2",
+			wantStatus: http.StatusMovedPermanently,
+			wantTarget: "http://example.com/about?page=2",
+		},
+		{
+			name:       "configured scheme",
+			config:     CanonicalHostConfig{Host: "example.com", Scheme: "https"},
+			target:     "http://www.example.com/about",
+			wantStatus: http.StatusMovedPermanently,
+			wantTarget: "https://example.com/about",
+		},
+		{
+			name:       "non-GET keeps method",
+			config:     CanonicalHostConfig{Host: "example.com"},
+			method:     http.MethodPost,
+			target:     "http://www.example.com/contact",
+			wantStatus: http.StatusPermanentRedirect,
+			wantTarget: "http://example.com/contact",
+		},
+		{
+			name:       "untrusted forwarded proto",
+			config:     CanonicalHostConfig{Host: "example.com"},
+			target:     "http://www.example.com/about",
+			proto:      "https",
+			wantStatus: http.StatusMovedPermanently,
+			wantTarget: "http://example.com/about",
+		},
+		{
+			name:       "trusted forwarded proto",
+			config:     CanonicalHostConfig{Host: "example.com", TrustProxy: true},
+			target:     "http://www.example.com/about",
+			proto:      "https",
+			wantStatus: http.StatusMovedPermanently,
+			wantTarget: "https://example.com/about",
+		},
+		{
+			name:       "invalid forwarded proto",
+			config:     CanonicalHostConfig{Host: "example.com", TrustProxy: true},
+			target:     "http://www.example.com/about",
+			proto:      "javascript",
+			wantStatus: http.StatusMovedPermanently,
+			wantTarget: "http://example.com/about",
+		},
+		{
+			name:       "skipped prefix",
+			config:     CanonicalHostConfig{Host: "example.com", SkipPrefixes: []string{"/health/"}},
+			target:     "http://10.0.0.1/health/live",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "internal request",
+			config:     CanonicalHostConfig{Host: "example.com"},
+			target:     "http://example.org/about",
+			internal:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "disabled",
+			config:     CanonicalHostConfig{},
+			target:     "http://www.example.com/about",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.target, nil)
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			if tt.internal {
+				req = req.WithContext(fwctx.MarkInternalRequest(req.Context()))
+			}
+			rec := httptest.NewRecorder()
+
+			CanonicalHost(tt.config)(okHandler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantTarget {
+				t.Errorf("Location = %q, want %q", got, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestCanonicalHostEagerRevalidation(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := CanonicalHost(CanonicalHostConfig{Host: "statigo.dev"})(withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about</p>"))))
+	manager.SetRouter(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "http://statigo.dev/about", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Revalidation requests carry httptest's host, not the canonical one
+	manager.MarkStale("static", true)
+	deadline := time.Now().Add(2 * time.Second)
+	for renders.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := renders.Load(); got != 2 {
+		t.Fatalf("renders = %d, want the stale page re-rendered", got)
+	}
+
+	for time.Now().Before(deadline) {
+		if entry, _ := manager.Get("/about:en"); !entry.IsStale() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("entry still stale after eager revalidation")
+}
@@ -59,7 +59,7 @@ func StructuredLogger(log *slog.Logger) func(next http.Handler) http.Handler {
 				slog.Int("status", wrapped.statusCode),
 				slog.Int64("bytes", wrapped.written),
 				slog.Duration("duration", duration),
-				slog.String("request_id", requestID),
+				slog.String(logger.KeyRequestID, requestID),
 				slog.String("user_agent", r.UserAgent()),
 			)
 		})
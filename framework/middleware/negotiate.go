@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+)
+
+// negotiateFormat picks the served media type that best matches an Accept header.
+// The first format is the default, used when Accept is empty or matches nothing.
+func negotiateFormat(accept string, formats []string) string {
+	if len(formats) == 0 {
+		return ""
+	}
+
+	best := formats[0]
+	bestQuality := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, quality := parseAcceptPart(part)
+		if mediaRange == "" || quality <= bestQuality {
+			continue
+		}
+
+		for _, format := range formats {
+			if mediaTypeMatches(mediaRange, format) {
+				best = format
+				bestQuality = quality
+				break
+			}
+		}
+	}
+
+	return best
+}
+
+// parseAcceptPart splits one Accept element into its media range and quality.
+func parseAcceptPart(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+
+	quality := 1.0
+	for _, param := range params[1:] {
+		name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found && strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = q
+			}
+		}
+	}
+
+	return mediaRange, quality
+}
+
+// mediaTypeMatches reports whether a media range such as "text/*" covers a media type.
+func mediaTypeMatches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if prefix, found := strings.CutSuffix(mediaRange, "/*"); found {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return false
+}
+
+// formatVariant returns the cache key variant for a negotiated format.
+// The default (first) format uses no variant so its keys stay unchanged.
+func formatVariant(format string, formats []string) string {
+	if len(formats) == 0 || format == formats[0] {
+		return ""
+	}
+	if _, subtype, found := strings.Cut(format, "/"); found {
+		return subtype
+	}
+	return format
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	fwctx "statigo/framework/context"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	formats := []string{"text/html", "application/json"}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"empty", "", "text/html"},
+		{"exact", "application/json", "application/json"},
+		{"wildcard", "*/*", "text/html"},
+		{"type wildcard", "application/*", "application/json"},
+		{"quality", "text/html;q=0.5, application/json;q=0.9", "application/json"},
+		{"no match", "image/png", "text/html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateFormat(tt.accept, formats); got != tt.want {
+				t.Errorf("negotiateFormat(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareFormats(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.Formats = []string{"text/html", "application/json"}
+
+	var renders atomic.Int32
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		if fwctx.GetFormat(r.Context()) == "application/json" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"title": "About"})
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, "<h1>About</h1>")
+	})
+	handler := withRoute("/about", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(page))
+
+	html := http.Header{"Accept": {"text/html"}}
+	jsonAccept := http.Header{"Accept": {"application/json"}}
+	serve(handler, "/about", html)
+	serve(handler, "/about", jsonAccept)
+
+	tests := []struct {
+		header      http.Header
+		contentType string
+		body        string
+	}{
+		{html, "text/html; charset=utf-8", "<h1>About</h1>"},
+		{jsonAccept, "application/json", `{"title":"About"}` + "\n"},
+	}
+	for _, tt := range tests {
+		rec := serve(handler, "/about", tt.header)
+		if got := rec.Header().Get("X-Cache"); got != "HIT" {
+			t.Errorf("Accept %q: X-Cache = %q, want HIT", tt.header.Get("Accept"), got)
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", tt.header.Get("Accept"), got, tt.contentType)
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("Accept %q: body = %q, want %q", tt.header.Get("Accept"), rec.Body.String(), tt.body)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", tt.header.Get("Accept"), got)
+		}
+	}
+
+	if got := renders.Load(); got != 2 {
+		t.Errorf("renders = %d, want one per format", got)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// byteRange is a satisfiable byte range within a response body (end inclusive).
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+// parseRange parses a single-range "bytes=" Range header against a body of the given size.
+// It returns ok=false for headers the cache does not honor (malformed or multi-range),
+// so the full body is served, and satisfiable=false when the range lies outside the body.
+func parseRange(header string, size int64) (r byteRange, ok, satisfiable bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return r, false, false
+	}
+
+	startStr, endStr, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return r, false, false
+	}
+
+	// Suffix range: the last N bytes
+	if startStr == "" {
+		suffix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || suffix < 0 {
+			return r, false, false
+		}
+		if suffix == 0 || size == 0 {
+			return r, true, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return byteRange{start: size - suffix, end: size - 1}, true, true
+	}
+
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 {
+		return r, false, false
+	}
+
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return r, false, false
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+
+	if start >= size {
+		return r, true, false
+	}
+
+	return byteRange{start: start, end: end}, true, true
+}
+
+// serveRange writes content honoring a Range header, answering 206 Partial Content
+// or 416 Range Not Satisfiable. It returns false when the full body should be served.
+func serveRange(w http.ResponseWriter, r *http.Request, content []byte, etag string) bool {
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader == "" {
+		return false
+	}
+
+	// If-Range only applies the range when the client's copy is current
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" && !strongETagMatch(ifRange, etag) {
+		return false
+	}
+
+	size := int64(len(content))
+	rng, ok, satisfiable := parseRange(rangeHeader, size)
+	if !ok {
+		return false
+	}
+
+	if !satisfiable {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		http.Error(w, "Requested Range Not Satisfiable", http.StatusRequestedRangeNotSatisfiable)
+		return true
+	}
+
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", rng.start, rng.end, size))
+	w.Header().Set("Content-Length", strconv.FormatInt(rng.end-rng.start+1, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(content[rng.start : rng.end+1])
+	return true
+}
+
+// strongETagMatch compares two entity tags with the strong comparison If-Range
+// requires (RFC 7233 §3.2): weak validators never match, so a weak ETag, or an
+// If-Range date, always results in the full response.
+func strongETagMatch(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if strings.HasPrefix(a, "W/") || strings.HasPrefix(b, "W/") || !strings.HasPrefix(a, `"`) {
+		return false
+	}
+	return a == b
+}
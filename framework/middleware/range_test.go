@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServeRange(t *testing.T) {
+	content := []byte("0123456789")
+
+	tests := []struct {
+		name         string
+		rangeHeader  string
+		handled      bool
+		wantStatus   int
+		wantBody     string
+		contentRange string
+	}{
+		{"valid range", "bytes=2-5", true, http.StatusPartialContent, "2345", "bytes 2-5/10"},
+		{"open-ended range", "bytes=7-", true, http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"suffix range", "bytes=-3", true, http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"end past the body", "bytes=8-20", true, http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{"unsatisfiable range", "bytes=20-30", true, http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+		{"empty suffix", "bytes=-0", true, http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+		{"multiple ranges", "bytes=0-1,4-5", false, 0, "", ""},
+		{"malformed", "bytes=abc", false, 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Range", tt.rangeHeader)
+			rec := httptest.NewRecorder()
+
+			if got := serveRange(rec, req, content, `"abc"`); got != tt.handled {
+				t.Fatalf("serveRange = %v, want %v", got, tt.handled)
+			}
+			if !tt.handled {
+				return
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Range"); got != tt.contentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.contentRange)
+			}
+			if tt.wantStatus == http.StatusPartialContent && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServeRangeIfRange(t *testing.T) {
+	content := []byte("0123456789")
+
+	tests := []struct {
+		name    string
+		ifRange string
+		etag    string
+		partial bool
+	}{
+		{"matching strong ETag", `"abc"`, `"abc"`, true},
+		{"stale strong ETag", `"old"`, `"abc"`, false},
+		{"weak response ETag", `W/"abc"`, `W/"abc"`, false},
+		{"weak If-Range", `W/"abc"`, `"abc"`, false},
+		{"weak ETag, strong If-Range", `"abc"`, `W/"abc"`, false},
+		{"date", "Wed, 21 Oct 2015 07:28:00 GMT", `"abc"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Range", "bytes=0-3")
+			req.Header.Set("If-Range", tt.ifRange)
+			rec := httptest.NewRecorder()
+
+			if got := serveRange(rec, req, content, tt.etag); got != tt.partial {
+				t.Fatalf("serveRange = %v, want %v", got, tt.partial)
+			}
+			if tt.partial && rec.Code != http.StatusPartialContent {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusPartialContent)
+			}
+		})
+	}
+}
+
+func TestCacheMiddlewareIfRangeWeakETag(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about us</p>")))
+
+	etag := serve(handler, "/about", nil).Header().Get("ETag")
+
+	// Default ETags are weak, so If-Range can never match and the full body is sent
+	rec := serve(handler, "/about", http.Header{"Range": {"bytes=0-3"}, "If-Range": {etag}})
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != "<p>about us</p>" {
+		t.Errorf("body = %q, want the full page", rec.Body.String())
+	}
+}
+
+func TestCacheMiddlewareRange(t *testing.T) {
+	manager := newTestManager(t)
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddleware(manager, discardLogger)(countingHandler(&renders, "<p>about us</p>")))
+
+	serve(handler, "/about", nil)
+	rec := serve(handler, "/about", http.Header{"Range": {"bytes=3-7"}})
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusPartialContent)
+	}
+	if rec.Body.String() != "about" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "about")
+	}
+	if got := rec.Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want bytes", got)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"golang.org/x/time/rate"
+
+	fwctx "statigo/framework/context"
 )
 
 // RateLimiterConfig configures the rate limiter middleware.
@@ -72,8 +74,8 @@ func RateLimiter(config RateLimiterConfig) func(http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Bypass rate limiting for internal bootstrap requests
-			if r.Header.Get("X-Internal-Bootstrap") == "true" {
+			// Bypass rate limiting for internal bootstrap and revalidation requests
+			if fwctx.IsInternalRequest(r.Context()) || fwctx.IsRevalidationRequest(r.Context()) {
 				next.ServeHTTP(w, r)
 				return
 			}
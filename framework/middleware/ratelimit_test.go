@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fwctx "statigo/framework/context"
+)
+
+func TestRateLimiterBootstrapBypass(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	limited := RateLimiter(RateLimiterConfig{RPS: 1, Burst: 1})(ok)
+
+	// The bootstrap header is client-controlled and must not lift the limit
+	header := http.Header{"X-Internal-Bootstrap": {"true"}}
+	serve(limited, "/", header)
+	if rec := serve(limited, "/", header); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("header bypass: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	// In-process warm requests are exempt
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(fwctx.MarkInternalRequest(req.Context()))
+	rec := httptest.NewRecorder()
+	limited.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("internal request: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// resolveTheme picks the requested theme from the query parameter or cookie.
+// Unknown or missing preferences fall back to the first configured theme.
+func resolveTheme(r *http.Request, config CacheMiddlewareConfig) string {
+	if len(config.Themes) == 0 {
+		return ""
+	}
+
+	var preference string
+	if config.ThemeParam != "" {
+		preference = r.URL.Query().Get(config.ThemeParam)
+	}
+	if preference == "" && config.ThemeCookie != "" {
+		if cookie, err := r.Cookie(config.ThemeCookie); err == nil {
+			preference = cookie.Value
+		}
+	}
+
+	for _, theme := range config.Themes {
+		if strings.EqualFold(preference, theme) {
+			return theme
+		}
+	}
+	return config.Themes[0]
+}
+
+// themeVariant returns the cache key variant for a theme.
+// The default theme has no variant, so its keys match unthemed ones.
+func themeVariant(theme string, themes []string) string {
+	if len(themes) == 0 || theme == themes[0] {
+		return ""
+	}
+	return "theme-" + strings.ToLower(theme)
+}
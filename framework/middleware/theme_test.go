@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	fwctx "statigo/framework/context"
+)
+
+func TestCacheMiddlewareThemes(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.Themes = []string{"light", "dark"}
+
+	var renders atomic.Int32
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, `<body class="`+fwctx.GetTheme(r.Context())+`">`)
+	})
+	handler := withRoute("/about", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(page))
+
+	darkCookie := http.Header{"Cookie": {"theme=dark"}}
+	tests := []struct {
+		name   string
+		target string
+		header http.Header
+		want   string
+	}{
+		{"no preference", "/about", nil, `<body class="light">`},
+		{"cookie", "/about", darkCookie, `<body class="dark">`},
+		{"query overrides cookie", "/about?theme=light", darkCookie, `<body class="light">`},
+		{"case-insensitive", "/about?theme=DARK", nil, `<body class="dark">`},
+		{"unknown theme", "/about?theme=neon", nil, `<body class="light">`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, tt.target, tt.header)
+			if rec.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.want)
+			}
+			if got := rec.Header().Values("Vary"); len(got) == 0 || got[len(got)-1] != "Cookie" {
+				t.Errorf("Vary = %v, want Cookie", got)
+			}
+		})
+	}
+
+	// Each theme is rendered once and then served from its own entry
+	if got := renders.Load(); got != 2 {
+		t.Errorf("renders = %d, want one per theme", got)
+	}
+}
+
+func TestCacheMiddlewareThemesTombstoned(t *testing.T) {
+	manager := newTestManager(t)
+	config := DefaultCacheMiddlewareConfig()
+	config.Themes = []string{"light", "dark"}
+
+	var renders atomic.Int32
+	handler := withRoute("/about", "en", "static",
+		CacheMiddlewareWithConfig(manager, config, discardLogger)(countingHandler(&renders, "<p>about</p>")))
+	serve(handler, "/about?theme=dark", nil)
+
+	if err := manager.Tombstone("/about", "en"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+
+	// Every theme of a removed page is gone, cached or not
+	for _, target := range []string{"/about", "/about?theme=dark"} {
+		if rec := serve(handler, target, nil); rec.Code != http.StatusGone {
+			t.Errorf("%s: status = %d, want 410", target, rec.Code)
+		}
+	}
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want only the render before the tombstone", got)
+	}
+}
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"statigo/framework/i18n"
+)
+
+// TimeoutConfig configures the request timeout middleware.
+type TimeoutConfig struct {
+	Duration   time.Duration // Time a handler may take before the timeout response is sent
+	Status     int           // Status of the timeout response (default: 503)
+	I18n       *i18n.I18n    // Optional translations for the timeout message
+	MessageKey string        // Translation key of the timeout message
+	Message    string        // Message used when no translation is available
+}
+
+// DefaultTimeoutConfig returns default configuration.
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Duration:   30 * time.Second,
+		Status:     http.StatusServiceUnavailable,
+		MessageKey: "errors.timeout",
+		Message:    "Service Unavailable",
+	}
+}
+
+// Timeout creates a middleware that cancels requests taking longer than d.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	config := DefaultTimeoutConfig()
+	config.Duration = d
+	return TimeoutWithConfig(config)
+}
+
+// TimeoutWithConfig creates a request timeout middleware with custom configuration.
+// The handler's response is buffered and only written once it completes in time,
+// so a timed-out request never leaks a partial body; writes after the deadline fail
+// with http.ErrHandlerTimeout. The request context is cancelled on expiry, which also
+// keeps the cache middleware from storing the abandoned render.
+func TimeoutWithConfig(config TimeoutConfig) func(http.Handler) http.Handler {
+	if config.Status == 0 {
+		config.Status = http.StatusServiceUnavailable
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.Duration <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), config.Duration)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// Re-raise on the request goroutine so Recoverer handles it
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Header().Set("Cache-Control", "no-store")
+				w.WriteHeader(config.Status)
+				w.Write([]byte(timeoutMessage(r, config)))
+			}
+		})
+	}
+}
+
+// timeoutMessage returns the timeout message in the request's language.
+func timeoutMessage(r *http.Request, config TimeoutConfig) string {
+	if config.I18n == nil || config.MessageKey == "" {
+		return config.Message
+	}
+
+	lang := GetLanguage(r.Context())
+	if lang == "" {
+		lang = config.I18n.DefaultLanguage()
+	}
+
+	if message := config.I18n.Get(lang, config.MessageKey); message != config.MessageKey {
+		return message
+	}
+	return config.Message
+}
+
+// timeoutWriter buffers a handler's response until it completes or times out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	buf      bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header returns the buffered response headers.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader records the status code.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// Write buffers the body, failing once the request has timed out.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
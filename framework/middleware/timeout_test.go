@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"statigo/framework/cache"
+	fwctx "statigo/framework/context"
+	"statigo/framework/i18n"
+)
+
+func TestTimeoutSkipsCaching(t *testing.T) {
+	manager := newTestManager(t)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		io.WriteString(w, "<p>too late</p>")
+	})
+
+	// finished closes once the cache middleware is done with the abandoned render
+	finished := make(chan struct{})
+	inner := withRoute("/slow", "en", "static", CacheMiddleware(manager, discardLogger)(slow))
+	handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(finished)
+		inner.ServeHTTP(w, r)
+	}))
+
+	rec := serve(handler, "/slow", nil)
+	<-finished
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec.Body.String() != "Service Unavailable" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	if _, ok := manager.Get(cache.GetCacheKey("/slow", "en", nil)); ok {
+		t.Error("render abandoned by the timeout was cached")
+	}
+}
+
+func TestTimeoutPassesFastResponses(t *testing.T) {
+	var renders atomic.Int32
+	handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		w.Header().Set("X-Page", "about")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "<p>about</p>")
+	}))
+
+	rec := serve(handler, "/about", nil)
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "<p>about</p>" || rec.Header().Get("X-Page") != "about" {
+		t.Errorf("response = %d %q %v, want the handler's response", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeoutLocalizedMessage(t *testing.T) {
+	translations, err := i18n.New(fstest.MapFS{
+		"en.json": {Data: []byte(`{"errors": {"timeout": "Please try again"}}`)},
+		"tr.json": {Data: []byte(`{"errors": {"timeout": "Lütfen tekrar deneyin"}}`)},
+	}, "en")
+	if err != nil {
+		t.Fatalf("i18n.New: %v", err)
+	}
+
+	config := DefaultTimeoutConfig()
+	config.Duration = 10 * time.Millisecond
+	config.I18n = translations
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	handler := TimeoutWithConfig(config)(slow)
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"tr", "Lütfen tekrar deneyin"},
+		{"", "Please try again"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.lang != "" {
+			req = req.WithContext(fwctx.SetLanguage(req.Context(), tt.lang))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Body.String() != tt.want {
+			t.Errorf("lang %q: body = %q, want %q", tt.lang, rec.Body.String(), tt.want)
+		}
+	}
+}
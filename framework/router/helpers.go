@@ -3,6 +3,7 @@ package router
 import (
 	"fmt"
 	"html/template"
+	"strings"
 )
 
 // SEOHelpers provides template functions for SEO optimization.
@@ -83,6 +84,16 @@ func (sh *SEOHelpers) GetLocalePath(canonical string, lang string) string {
 	return "/"
 }
 
+// GetAbsoluteURL returns the full URL for a site-relative path.
+// Paths that are already absolute URLs are returned unchanged.
+func (sh *SEOHelpers) GetAbsoluteURL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+
+	return strings.TrimSuffix(sh.deployURL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
 // SEOFunctions holds SEO-related template functions.
 // This struct is used to pass SEO functions to the template renderer.
 type SEOFunctions struct {
@@ -90,6 +101,7 @@ type SEOFunctions struct {
 	AlternateLinks func(canonical string) template.HTML
 	AlternateURLs  func(canonical string) map[string]string
 	LocalePath     func(canonical, lang string) string
+	AbsoluteURL    func(path string) string
 }
 
 // ToTemplateFunctions converts SEOHelpers to a SEOFunctions struct.
@@ -99,5 +111,6 @@ func (sh *SEOHelpers) ToTemplateFunctions() *SEOFunctions {
 		AlternateLinks: sh.GetAlternateLinks,
 		AlternateURLs:  sh.GetAlternateURLs,
 		LocalePath:     sh.GetLocalePath,
+		AbsoluteURL:    sh.GetAbsoluteURL,
 	}
 }
@@ -0,0 +1,62 @@
+package router
+
+import (
+	"testing"
+)
+
+func newTestSEOHelpers(t *testing.T) *SEOHelpers {
+	t.Helper()
+	registry := NewRegistry([]string{"en", "tr"})
+	routes := []RouteDefinition{
+		{Canonical: "/", Paths: map[string]string{"en": "/", "tr": "/tr"}},
+		{Canonical: "/about", Paths: map[string]string{"en": "/en/about", "tr": "/tr/hakkimizda"}},
+	}
+	for _, route := range routes {
+		if err := registry.AddRoute(route); err != nil {
+			t.Fatalf("AddRoute: %v", err)
+		}
+	}
+	return NewSEOHelpers(registry, "https://example.com")
+}
+
+func TestGetLocalePath(t *testing.T) {
+	helpers := newTestSEOHelpers(t)
+
+	tests := []struct {
+		canonical string
+		lang      string
+		want      string
+	}{
+		{"/about", "en", "/en/about"},
+		{"/about", "tr", "/tr/hakkimizda"},
+		{"/", "en", "/"}, // The default language is served from the bare root
+		{"/", "tr", "/tr"},
+		{"/about", "de", "/"},
+		{"/missing", "en", "/"},
+	}
+
+	for _, tt := range tests {
+		if got := helpers.GetLocalePath(tt.canonical, tt.lang); got != tt.want {
+			t.Errorf("GetLocalePath(%q, %q) = %q, want %q", tt.canonical, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestGetAbsoluteURL(t *testing.T) {
+	helpers := newTestSEOHelpers(t)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/images/og.png", "https://example.com/images/og.png"},
+		{"images/og.png", "https://example.com/images/og.png"},
+		{"https://cdn.example.com/og.png", "https://cdn.example.com/og.png"},
+	}
+
+	for _, tt := range tests {
+		if got := helpers.GetAbsoluteURL(tt.path); got != tt.want {
+			t.Errorf("GetAbsoluteURL(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
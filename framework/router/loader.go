@@ -20,6 +20,7 @@ type RouteConfig struct {
 	Handler   string            `json:"handler"`  // Handler name (e.g., "index", "content")
 	Title     string            `json:"title"`    // Translation key for page title
 	Strategy  string            `json:"strategy"` // Caching strategy: "static", "incremental", "dynamic", "immutable"
+	Headers   map[string]string `json:"headers"`  // Response headers sent for this route on cache hits and misses
 }
 
 // RoutesConfig represents the complete routes configuration file.
@@ -127,6 +128,7 @@ func LoadRoutesFromJSON(
 			Template:  routeConfig.Template,
 			Title:     routeConfig.Title,
 			Strategy:  routeConfig.Strategy,
+			Headers:   routeConfig.Headers,
 		}); err != nil {
 			return fmt.Errorf("failed to add route %s: %w", routeConfig.Canonical, err)
 		}
@@ -8,7 +8,9 @@ import (
 )
 
 // CanonicalPathMiddleware creates middleware that stores canonical path,
-// page title, and cache strategy in the request context.
+// page title, and cache strategy in the request context, and sets the
+// route's configured headers. Since it runs ahead of the cache middleware,
+// those headers are sent on cache hits as well as fresh renders.
 func CanonicalPathMiddleware(registry *Registry) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -21,7 +23,10 @@ func CanonicalPathMiddleware(registry *Registry) func(http.Handler) http.Handler
 					ctx = fwctx.SetPageTitle(ctx, route.Title)
 				}
 				if route.Strategy != "" {
-					ctx = fwctx.SetStrategy(ctx, route.Strategy)
+					ctx = fwctx.SetStrategyResolution(ctx, route.Strategy, fwctx.StrategySourceRoute)
+				}
+				for name, value := range route.Headers {
+					w.Header().Set(name, value)
 				}
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
@@ -47,3 +52,9 @@ func GetPageTitle(ctx context.Context) string {
 func GetStrategy(ctx context.Context) string {
 	return fwctx.GetStrategy(ctx)
 }
+
+// OverrideStrategy replaces the cache strategy for the current response,
+// e.g. downgrading to "dynamic" when a handler renders an error fragment.
+func OverrideStrategy(ctx context.Context, strategy string) bool {
+	return fwctx.OverrideStrategy(ctx, strategy)
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"statigo/framework/cache"
+	fwctx "statigo/framework/context"
+	"statigo/framework/middleware"
+)
+
+func TestRouteHeadersOnCacheHits(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	manager, err := cache.NewManager(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	manager.SetMemoryOnlyStrategies("static")
+
+	registry := NewRegistry([]string{"en"})
+	err = registry.AddRoute(RouteDefinition{
+		Canonical: "/about",
+		Paths:     map[string]string{"en": "/en/about"},
+		Strategy:  "static",
+		Headers:   map[string]string{"Surrogate-Key": "about", "CDN-Cache-Control": "max-age=600"},
+	})
+	if err != nil {
+		t.Fatalf("AddRoute: %v", err)
+	}
+
+	var renders atomic.Int32
+	page := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders.Add(1)
+		io.WriteString(w, "<p>about</p>")
+	})
+	withLanguage := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(fwctx.SetLanguage(r.Context(), "en")))
+		})
+	}
+	handler := withLanguage(CanonicalPathMiddleware(registry)(
+		middleware.CacheMiddleware(manager, logger)(page)))
+
+	for _, want := range []string{"MISS", "HIT"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/en/about", nil))
+
+		if got := rec.Header().Get("X-Cache"); got != want {
+			t.Fatalf("X-Cache = %q, want %s", got, want)
+		}
+		if got := rec.Header().Get("Surrogate-Key"); got != "about" {
+			t.Errorf("%s: Surrogate-Key = %q, want about", want, got)
+		}
+		if got := rec.Header().Get("CDN-Cache-Control"); got != "max-age=600" {
+			t.Errorf("%s: CDN-Cache-Control = %q", want, got)
+		}
+	}
+	if got := renders.Load(); got != 1 {
+		t.Errorf("renders = %d, want 1", got)
+	}
+}
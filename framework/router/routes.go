@@ -16,6 +16,7 @@ type RouteDefinition struct {
 	Template  string            // Template name (e.g., "content.html")
 	Title     string            // Translation key for page title (e.g., "main.title")
 	Strategy  string            // Caching strategy: "static", "incremental", "dynamic", "immutable"
+	Headers   map[string]string // Route-level response headers (e.g. CDN hints), sent on cache hits and misses
 }
 
 // Registry maintains the mapping between canonical paths and route definitions.
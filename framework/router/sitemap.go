@@ -0,0 +1,272 @@
+package router
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// sitemapNamespace is the XML namespace of the sitemaps.org protocol.
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// ParamProvider expands a parameterized route (e.g. "/en/blog/{slug}") into
+// the concrete paths to list for a language.
+type ParamProvider func(route RouteDefinition, lang string) []string
+
+// SitemapPartition decides how a sitemap index splits pages into child sitemaps.
+type SitemapPartition string
+
+const (
+	PartitionByLanguage SitemapPartition = "language" // One child per language, e.g. sitemap-en.xml
+	PartitionBySection  SitemapPartition = "section"  // One child per top-level path segment, e.g. sitemap-blog.xml
+)
+
+// SitemapConfig configures sitemap generation.
+type SitemapConfig struct {
+	Params    ParamProvider    // Expands parameterized routes; without it they are skipped
+	Partition SitemapPartition // How the sitemap index splits pages (default: by language)
+	Logger    *slog.Logger
+}
+
+// DefaultSitemapConfig returns default configuration.
+func DefaultSitemapConfig() SitemapConfig {
+	return SitemapConfig{
+		Params:    nil,
+		Partition: PartitionByLanguage,
+		Logger:    slog.Default(),
+	}
+}
+
+// SitemapChild is one partition of a sitemap index.
+type SitemapChild struct {
+	Name string   // Partition name; the child is served at /sitemap-{name}.xml
+	URLs []string // Absolute URLs of the partition's pages
+}
+
+// sitemapPage is a listable page along with what it can be partitioned by.
+type sitemapPage struct {
+	url  string
+	lang string
+	path string
+}
+
+// sitemapIndex is the <sitemapindex> document.
+type sitemapIndex struct {
+	XMLName  xml.Name         `xml:"sitemapindex"`
+	Xmlns    string           `xml:"xmlns,attr"`
+	Sitemaps []sitemapLocator `xml:"sitemap"`
+}
+
+// sitemapLocator is a single <sitemap> entry of an index.
+type sitemapLocator struct {
+	Loc string `xml:"loc"`
+}
+
+// sitemapURLSet is the <urlset> document.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// sitemapURL is a single <url> entry.
+type sitemapURL struct {
+	Loc string `xml:"loc"`
+}
+
+// SitemapURLs returns the absolute URLs of every listable page, in route order.
+// Dynamic routes and paths with unsubstituted "{param}" placeholders are skipped;
+// parameterized routes are included through the configured ParamProvider.
+func (sh *SEOHelpers) SitemapURLs(config SitemapConfig) []string {
+	var urls []string
+	for _, page := range sh.sitemapPages(config) {
+		urls = append(urls, page.url)
+	}
+	return urls
+}
+
+// sitemapPages lists every listable page once, in route order.
+func (sh *SEOHelpers) sitemapPages(config SitemapConfig) []sitemapPage {
+	var pages []sitemapPage
+	seen := make(map[string]bool)
+
+	for _, route := range sh.registry.GetAll() {
+		if route.Strategy == "dynamic" {
+			config.Logger.Debug("Skipping dynamic route in sitemap",
+				slog.String("canonical", route.Canonical),
+			)
+			continue
+		}
+
+		for _, lang := range sh.registry.Languages() {
+			paths := []string{route.Paths[lang]}
+			if strings.Contains(route.Canonical, "{") || strings.Contains(paths[0], "{") {
+				paths = nil
+				if config.Params != nil {
+					paths = config.Params(route, lang)
+				}
+			}
+
+			for _, path := range paths {
+				if path == "" || strings.Contains(path, "{") {
+					config.Logger.Debug("Skipping unexpanded path in sitemap",
+						slog.String("canonical", route.Canonical),
+						slog.String("lang", lang),
+						slog.String("path", path),
+					)
+					continue
+				}
+
+				url := sh.GetAbsoluteURL(path)
+				if !seen[url] {
+					seen[url] = true
+					pages = append(pages, sitemapPage{url: url, lang: lang, path: path})
+				}
+			}
+		}
+	}
+
+	return pages
+}
+
+// SitemapChildren splits the listable pages into child sitemaps according to
+// the configured partition, in order of first appearance.
+func (sh *SEOHelpers) SitemapChildren(config SitemapConfig) []SitemapChild {
+	var children []SitemapChild
+	index := make(map[string]int)
+
+	for _, page := range sh.sitemapPages(config) {
+		name := page.lang
+		if config.Partition == PartitionBySection {
+			name = pathSection(page.path, page.lang)
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(children)
+			index[name] = i
+			children = append(children, SitemapChild{Name: name})
+		}
+		children[i].URLs = append(children[i].URLs, page.url)
+	}
+
+	return children
+}
+
+// pathSection returns the first path segment after the language prefix,
+// or "root" for the language's home page.
+func pathSection(path, lang string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && segments[0] == lang {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || segments[0] == "" {
+		return "root"
+	}
+	return strings.ToLower(segments[0])
+}
+
+// SitemapIndex renders a <sitemapindex> referencing every child sitemap.
+func (sh *SEOHelpers) SitemapIndex(config SitemapConfig) ([]byte, error) {
+	index := sitemapIndex{Xmlns: sitemapNamespace}
+	for _, child := range sh.SitemapChildren(config) {
+		index.Sitemaps = append(index.Sitemaps, sitemapLocator{
+			Loc: sh.GetAbsoluteURL("/sitemap-" + child.Name + ".xml"),
+		})
+	}
+
+	content, err := xml.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sitemap index: %w", err)
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
+
+// SitemapChild renders the child sitemap with the given partition name.
+// The second return value is false when no such partition exists.
+func (sh *SEOHelpers) SitemapChild(config SitemapConfig, name string) ([]byte, bool, error) {
+	for _, child := range sh.SitemapChildren(config) {
+		if child.Name == name {
+			content, err := renderURLSet(child.URLs)
+			return content, true, err
+		}
+	}
+	return nil, false, nil
+}
+
+// Sitemap renders the sitemap.xml document.
+func (sh *SEOHelpers) Sitemap(config SitemapConfig) ([]byte, error) {
+	return renderURLSet(sh.SitemapURLs(config))
+}
+
+// SitemapHandler serves the sitemap.xml document.
+func (sh *SEOHelpers) SitemapHandler(config SitemapConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, err := sh.Sitemap(config)
+		if err != nil {
+			config.Logger.Error("Failed to render sitemap", slog.String("error", err.Error()))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(content)
+	}
+}
+
+// SitemapIndexHandler serves the sitemap index document.
+func (sh *SEOHelpers) SitemapIndexHandler(config SitemapConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		content, err := sh.SitemapIndex(config)
+		if err != nil {
+			config.Logger.Error("Failed to render sitemap index", slog.String("error", err.Error()))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(content)
+	}
+}
+
+// SitemapChildHandler serves child sitemaps at /sitemap-{name}.xml.
+func (sh *SEOHelpers) SitemapChildHandler(config SitemapConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/sitemap-"), ".xml")
+
+		content, found, err := sh.SitemapChild(config, name)
+		if err != nil {
+			config.Logger.Error("Failed to render sitemap",
+				slog.String("name", name),
+				slog.String("error", err.Error()),
+			)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.Write(content)
+	}
+}
+
+// renderURLSet encodes URLs as a <urlset> document.
+func renderURLSet(urls []string) ([]byte, error) {
+	set := sitemapURLSet{Xmlns: sitemapNamespace}
+	for _, url := range urls {
+		set.URLs = append(set.URLs, sitemapURL{Loc: url})
+	}
+
+	content, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sitemap: %w", err)
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
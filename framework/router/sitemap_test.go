@@ -0,0 +1,119 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newSitemapHelpers returns helpers for a site with static, dynamic and templated routes.
+func newSitemapHelpers(t *testing.T) *SEOHelpers {
+	t.Helper()
+	registry := NewRegistry([]string{"en", "tr"})
+	routes := []RouteDefinition{
+		{Canonical: "/about", Paths: map[string]string{"en": "/en/about", "tr": "/tr/hakkimizda"}, Strategy: "static"},
+		{Canonical: "/search", Paths: map[string]string{"en": "/en/search", "tr": "/tr/ara"}, Strategy: "dynamic"},
+		{Canonical: "/blog/{slug}", Paths: map[string]string{"en": "/en/blog/{slug}", "tr": "/tr/blog/{slug}"}, Strategy: "incremental"},
+	}
+	for _, route := range routes {
+		if err := registry.AddRoute(route); err != nil {
+			t.Fatalf("AddRoute: %v", err)
+		}
+	}
+	return NewSEOHelpers(registry, "https://example.com")
+}
+
+// testSitemapConfig returns a sitemap configuration that discards logs.
+func testSitemapConfig() SitemapConfig {
+	config := DefaultSitemapConfig()
+	config.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return config
+}
+
+func TestSitemapURLs(t *testing.T) {
+	helpers := newSitemapHelpers(t)
+	config := testSitemapConfig()
+
+	// Dynamic routes and unexpanded templates are left out
+	want := []string{"https://example.com/en/about", "https://example.com/tr/hakkimizda"}
+	if got := helpers.SitemapURLs(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("SitemapURLs = %v, want %v", got, want)
+	}
+
+	// A param provider expands templated routes; leftover placeholders are still skipped
+	config.Params = func(route RouteDefinition, lang string) []string {
+		path := route.Paths[lang]
+		return []string{strings.Replace(path, "{slug}", "hello", 1), path}
+	}
+	want = append(want, "https://example.com/en/blog/hello", "https://example.com/tr/blog/hello")
+	if got := helpers.SitemapURLs(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("SitemapURLs with params = %v, want %v", got, want)
+	}
+}
+
+func TestSitemapChildren(t *testing.T) {
+	helpers := newSitemapHelpers(t)
+	config := testSitemapConfig()
+	config.Params = func(route RouteDefinition, lang string) []string {
+		return []string{strings.Replace(route.Paths[lang], "{slug}", "hello", 1)}
+	}
+
+	tests := []struct {
+		partition SitemapPartition
+		want      []SitemapChild
+	}{
+		{PartitionByLanguage, []SitemapChild{
+			{Name: "en", URLs: []string{"https://example.com/en/about", "https://example.com/en/blog/hello"}},
+			{Name: "tr", URLs: []string{"https://example.com/tr/hakkimizda", "https://example.com/tr/blog/hello"}},
+		}},
+		{PartitionBySection, []SitemapChild{
+			{Name: "about", URLs: []string{"https://example.com/en/about"}},
+			{Name: "hakkimizda", URLs: []string{"https://example.com/tr/hakkimizda"}},
+			{Name: "blog", URLs: []string{"https://example.com/en/blog/hello", "https://example.com/tr/blog/hello"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.partition), func(t *testing.T) {
+			config.Partition = tt.partition
+			if got := helpers.SitemapChildren(config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SitemapChildren = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSitemapIndexHandlers(t *testing.T) {
+	helpers := newSitemapHelpers(t)
+	config := testSitemapConfig()
+
+	rec := httptest.NewRecorder()
+	helpers.SitemapIndexHandler(config)(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	for _, loc := range []string{"https://example.com/sitemap-en.xml", "https://example.com/sitemap-tr.xml"} {
+		if !strings.Contains(rec.Body.String(), "<loc>"+loc+"</loc>") {
+			t.Errorf("index does not reference %s:\n%s", loc, rec.Body.String())
+		}
+	}
+
+	// Each child lists only its own partition
+	child := helpers.SitemapChildHandler(config)
+	rec = httptest.NewRecorder()
+	child(rec, httptest.NewRequest(http.MethodGet, "/sitemap-tr.xml", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("child status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "https://example.com/tr/hakkimizda") || strings.Contains(body, "/en/") {
+		t.Errorf("child sitemap-tr.xml = %s, want only Turkish pages", body)
+	}
+
+	rec = httptest.NewRecorder()
+	child(rec, httptest.NewRequest(http.MethodGet, "/sitemap-fr.xml", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown child status = %d, want 404", rec.Code)
+	}
+}
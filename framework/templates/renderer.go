@@ -21,6 +21,7 @@ type Renderer struct {
 	i18n          *i18n.I18n
 	minifier      *utils.Minifier
 	logger        *slog.Logger
+	version       string // Fingerprint of the loaded template files
 }
 
 // SEOFunctions holds SEO-related template functions.
@@ -29,6 +30,7 @@ type SEOFunctions struct {
 	AlternateLinks func(canonical string) template.HTML
 	AlternateURLs  func(canonical string) map[string]string
 	LocalePath     func(canonical, lang string) string
+	AbsoluteURL    func(path string) string
 }
 
 // NewRenderer creates a new template renderer.
@@ -64,12 +66,14 @@ func NewRenderer(templatesFS fs.FS, i18nInstance *i18n.I18n, seoFuncs *SEOFuncti
 		funcMap["alternateLinks"] = seoFuncs.AlternateLinks
 		funcMap["alternateURLs"] = seoFuncs.AlternateURLs
 		funcMap["localePath"] = seoFuncs.LocalePath
+		funcMap["absoluteURL"] = seoFuncs.AbsoluteURL
 	} else {
 		// Provide default no-op implementations
 		funcMap["canonicalURL"] = func(canonical, lang string) string { return "" }
 		funcMap["alternateLinks"] = func(canonical string) template.HTML { return "" }
 		funcMap["alternateURLs"] = func(canonical string) map[string]string { return nil }
 		funcMap["localePath"] = func(canonical, lang string) string { return "" }
+		funcMap["absoluteURL"] = func(path string) string { return path }
 	}
 
 	templates := template.New("base").Funcs(funcMap)
@@ -119,15 +123,27 @@ func NewRenderer(templatesFS fs.FS, i18nInstance *i18n.I18n, seoFuncs *SEOFuncti
 		pageTemplates[pageName] = pageTemplate
 	}
 
+	version, err := utils.HashFS(templatesFS)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Renderer{
 		templates:     templates,
 		pageTemplates: pageTemplates,
 		i18n:          i18nInstance,
 		minifier:      minifier,
 		logger:        logger,
+		version:       version,
 	}, nil
 }
 
+// ContentVersion returns a fingerprint of the loaded templates.
+// It changes whenever a template file is added, removed or edited.
+func (r *Renderer) ContentVersion() string {
+	return r.version
+}
+
 // GetTranslation returns a translation for the given language and key.
 func (r *Renderer) GetTranslation(lang, key string) string {
 	if value := r.i18n.GetRaw(lang, key); value != nil {
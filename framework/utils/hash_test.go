@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestHashFS(t *testing.T) {
+	hash := func(fsys fstest.MapFS) string {
+		t.Helper()
+		version, err := HashFS(fsys)
+		if err != nil {
+			t.Fatalf("HashFS: %v", err)
+		}
+		return version
+	}
+
+	base := hash(fstest.MapFS{"pages/index.html": {Data: []byte("<h1>Home</h1>")}})
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		same bool
+	}{
+		{"unchanged", fstest.MapFS{"pages/index.html": {Data: []byte("<h1>Home</h1>")}}, true},
+		{"edited", fstest.MapFS{"pages/index.html": {Data: []byte("<h1>Welcome</h1>")}}, false},
+		{"renamed", fstest.MapFS{"pages/home.html": {Data: []byte("<h1>Home</h1>")}}, false},
+		{"added", fstest.MapFS{
+			"pages/index.html": {Data: []byte("<h1>Home</h1>")},
+			"pages/about.html": {Data: []byte("<h1>About</h1>")},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hash(tt.fsys) == base; got != tt.same {
+				t.Errorf("hash unchanged = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
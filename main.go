@@ -7,10 +7,12 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -42,7 +44,12 @@ func main() {
 	if logLevel == "" {
 		logLevel = "INFO"
 	}
-	appLogger := fwlogger.InitLogger(logLevel)
+	logConfig := fwlogger.DefaultConfig()
+	logConfig.Level = logLevel
+	logConfig.Format = os.Getenv("LOG_FORMAT")
+	logConfig.Service = os.Getenv("SERVICE_NAME")
+	logConfig.Version = os.Getenv("CONTENT_VERSION")
+	appLogger := fwlogger.New(logConfig)
 
 	// Get embedded filesystems
 	translationsFS := GetTranslationsFS()
@@ -75,6 +82,7 @@ func main() {
 		AlternateLinks:  routerSEOFuncs.AlternateLinks,
 		AlternateURLs:   routerSEOFuncs.AlternateURLs,
 		LocalePath:     routerSEOFuncs.LocalePath,
+		AbsoluteURL:    routerSEOFuncs.AbsoluteURL,
 	}
 
 	// Initialize template renderer
@@ -84,22 +92,77 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Namespace cache keys by deploy so new templates/translations aren't masked.
+	// Set before the manager is created, which derives tombstoned keys from them.
+	cache.SetKeyOptions(cache.KeyOptions{
+		Version: os.Getenv("CONTENT_VERSION"),
+		Salt:    os.Getenv("CACHE_KEY_SALT"),
+	})
+
 	// Initialize cache manager
 	cacheDir := os.Getenv("CACHE_DIR")
 	if cacheDir == "" {
 		workDir, _ := os.Getwd()
 		cacheDir = filepath.Join(workDir, "data", "cache")
 	}
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		appLogger.Error("Failed to create cache directory", "error", err)
-		os.Exit(1)
-	}
-	cacheManager, err := cache.NewManager(cacheDir, appLogger)
+	// Fall back to memory-only caching on a read-only filesystem unless strict
+	managerConfig := cache.DefaultManagerConfig()
+	managerConfig.StrictStorage = utils.GetEnvBool("CACHE_STRICT_STORAGE", false)
+	cacheManager, err := cache.NewManagerWithConfig(cacheDir, managerConfig, appLogger)
 	if err != nil {
 		appLogger.Error("Failed to initialize cache manager", "error", err)
 		os.Exit(1)
 	}
-	appLogger.Info("Cache manager initialized", "dir", cacheDir)
+	appLogger.Info("Cache manager initialized", "dir", cacheDir, "persistent", cacheManager.Persistent())
+
+	// Store each language in its own directory, moving files from the previous layout
+	cacheManager.SetLanguageDirectories(utils.GetEnvBool("CACHE_LANGUAGE_DIRS", false))
+	if _, err := cacheManager.MigrateStorage(); err != nil {
+		appLogger.Error("Failed to migrate cache layout", "error", err)
+		os.Exit(1)
+	}
+
+	// Keep entry metadata in one index file instead of a sidecar per page
+	if err := cacheManager.SetMetadataIndex(utils.GetEnvBool("CACHE_META_INDEX", false)); err != nil {
+		appLogger.Error("Failed to load cache metadata index", "error", err)
+		os.Exit(1)
+	}
+
+	// Re-render pages cached with different templates or translations
+	cacheManager.SetContentVersion(renderer.ContentVersion() + i18nInstance.ContentVersion())
+
+	// Leave just-rendered pages alone when invalidations arrive in quick succession
+	cacheManager.SetMinFreshAge(time.Duration(utils.GetEnvInt("CACHE_MIN_FRESH_AGE", 0)) * time.Second)
+
+	// Retry cache file writes that hit transient disk errors
+	writeRetry := cache.DefaultWriteRetryConfig()
+	writeRetry.Attempts = utils.GetEnvInt("CACHE_WRITE_ATTEMPTS", writeRetry.Attempts)
+	cacheManager.SetWriteRetry(writeRetry)
+
+	// Never cache pages that rendered an error marker with a 200 status
+	if markers := os.Getenv("CACHE_REJECT_MARKERS"); markers != "" {
+		cacheManager.SetContentValidator(cache.RejectMarkers(strings.Split(markers, ",")...))
+	}
+
+	// Allow caching pages with statuses other than 200 (e.g. negative-caching 404s)
+	if statuses := os.Getenv("CACHE_STATUSES"); statuses != "" {
+		var codes []int
+		for _, status := range strings.Split(statuses, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(status))
+			if err != nil {
+				appLogger.Warn("Ignoring invalid cacheable status", "status", status)
+				continue
+			}
+			codes = append(codes, code)
+		}
+		cacheManager.SetCacheableStatuses(codes...)
+	}
+
+	// Cap memory used by cached pages; evicted pages are reloaded from disk
+	if budgetMB := utils.GetEnvInt("CACHE_MEMORY_BUDGET_MB", 0); budgetMB > 0 {
+		policy := cache.EvictionPolicy(os.Getenv("CACHE_EVICTION_POLICY"))
+		cacheManager.SetMemoryBudget(int64(budgetMB)<<20, policy)
+	}
 
 	// Initialize example handlers
 	indexHandler := handlers.NewIndexHandler(renderer, cacheManager, routeRegistry)
@@ -157,6 +220,18 @@ func main() {
 	// Apply middleware
 	r.Use(middleware.StructuredLogger(appLogger))
 	r.Use(chiMiddleware.Recoverer)
+
+	// Redirect other hosts (www, raw IPs) to the host of BASE_URL
+	if utils.GetEnvBool("CANONICAL_HOST_REDIRECT", false) {
+		hostConfig := middleware.DefaultCanonicalHostConfig()
+		if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+			hostConfig.Host = parsed.Host
+			hostConfig.Scheme = parsed.Scheme
+		}
+		hostConfig.TrustProxy = utils.GetEnvBool("TRUST_PROXY", false)
+		r.Use(middleware.CanonicalHost(hostConfig))
+	}
+
 	r.Use(middleware.IPBanMiddleware(ipBanList, appLogger))
 	r.Use(middleware.HoneypotMiddleware(ipBanList, honeypotPaths, appLogger))
 	r.Use(middleware.RateLimiter(middleware.RateLimiterConfig{
@@ -176,16 +251,44 @@ func main() {
 	langConfig := middleware.LanguageConfig{
 		SupportedLanguages: languages,
 		DefaultLanguage:    "en",
-		SkipPaths:          []string{"/robots.txt", "/sitemap.xml", "/favicon.ico"},
-		SkipPrefixes:       []string{"/health/", "/static/", "/styles/", "/scripts/"},
+		SkipPaths:          []string{"/robots.txt", "/sitemap.xml", "/sitemap_index.xml", "/favicon.ico"},
+		SkipPrefixes:       []string{"/health/", "/static/", "/styles/", "/scripts/", "/sitemap-"},
 	}
 	r.Use(middleware.Language(i18nInstance, langConfig))
 
 	// Canonical path middleware
 	r.Use(router.CanonicalPathMiddleware(routeRegistry))
 
+	// Request timeout middleware (wraps the cache so timed-out renders are never stored)
+	if requestTimeout := utils.GetEnvInt("REQUEST_TIMEOUT", 0); requestTimeout > 0 {
+		timeoutConfig := middleware.DefaultTimeoutConfig()
+		timeoutConfig.Duration = time.Duration(requestTimeout) * time.Second
+		timeoutConfig.I18n = i18nInstance
+		r.Use(middleware.TimeoutWithConfig(timeoutConfig))
+	}
+
 	// Cache middleware
-	r.Use(middleware.CacheMiddleware(cacheManager, appLogger))
+	cacheConfig := middleware.DefaultCacheMiddlewareConfig()
+	cacheConfig.Debug = utils.GetEnvBool("CACHE_DEBUG", false)
+	cacheConfig.CacheRedirects = utils.GetEnvBool("CACHE_REDIRECTS", false)
+	cacheConfig.WeakETags = utils.GetEnvBool("CACHE_WEAK_ETAGS", true)
+	if themes := os.Getenv("CACHE_THEMES"); themes != "" {
+		cacheConfig.Themes = strings.Split(themes, ",")
+	}
+	if statusHeader := os.Getenv("CACHE_STATUS_HEADER"); statusHeader != "" {
+		cacheConfig.StatusHeader = statusHeader
+	}
+	cacheConfig.ImmutableAfter = time.Duration(utils.GetEnvInt("CACHE_IMMUTABLE_AFTER", 0)) * time.Second
+	cacheConfig.MinBodySize = utils.GetEnvInt("CACHE_MIN_BODY_SIZE", 0)
+	for _, rule := range strings.Split(os.Getenv("CACHE_PREFIX_STRATEGIES"), ",") {
+		if prefix, strategy, found := strings.Cut(strings.TrimSpace(rule), "="); found {
+			cacheConfig.PrefixStrategies = append(cacheConfig.PrefixStrategies, middleware.PrefixStrategy{
+				Prefix:   prefix,
+				Strategy: strategy,
+			})
+		}
+	}
+	r.Use(middleware.CacheMiddlewareWithConfig(cacheManager, cacheConfig, appLogger))
 
 	// Register routes
 	routeRegistry.RegisterRoutes(r, func(h http.Handler) http.Handler { return h })
@@ -202,9 +305,28 @@ func main() {
 	r.Get("/health/livez", healthHandler.Liveness)
 	r.Get("/health/readz", healthHandler.Readiness)
 
+	// Sitemap of every static page in every language
+	sitemapConfig := router.DefaultSitemapConfig()
+	sitemapConfig.Logger = appLogger
+	if partition := os.Getenv("SITEMAP_PARTITION"); partition != "" {
+		sitemapConfig.Partition = router.SitemapPartition(partition)
+	}
+	r.Get("/sitemap.xml", seoHelpers.SitemapHandler(sitemapConfig))
+
+	// Sitemap index for large sites, split into child sitemaps by language or section
+	r.Get("/sitemap_index.xml", seoHelpers.SitemapIndexHandler(sitemapConfig))
+	r.Get("/sitemap-{name}.xml", seoHelpers.SitemapChildHandler(sitemapConfig))
+
 	// Set router on cache manager for revalidation
 	cacheManager.SetRouter(r)
 
+	// Optionally re-render stale pages in the background
+	if warmInterval := utils.GetEnvInt("CACHE_STALE_WARM_INTERVAL", 0); warmInterval > 0 {
+		staleWarmer := cache.NewStaleWarmer(cacheManager, appLogger)
+		staleWarmer.Start(time.Duration(warmInterval)*time.Second, utils.GetEnvInt("CACHE_STALE_WARM_CONCURRENCY", 4))
+		defer staleWarmer.Stop()
+	}
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {